@@ -0,0 +1,277 @@
+// Package asciicast reads and writes terminal sessions in asciinema's
+// asciicast v2 format: a JSON header line followed by one JSON array per event,
+//
+//	{"version": 2, "width": 80, "height": 24, "timestamp": 1700000000}
+//	[0.25, "o", "hello"]
+//	[1.5, "i", "q"]
+//
+// See https://docs.asciinema.org/manual/asciicast/v2/.
+package asciicast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tmc/bubbweb/internal/utf8util"
+)
+
+// Version is the asciicast format version this package writes.
+const Version = 2
+
+// Default terminal size, used in the header if no size is known when it must
+// be written.
+const (
+	DefaultWidth  = 80
+	DefaultHeight = 24
+)
+
+// Header is the first line of a recording.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Duration  float64           `json:"duration,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// EventType identifies the kind of an Event.
+type EventType string
+
+const (
+	// Output is data written to the terminal.
+	Output EventType = "o"
+	// Input is data typed at the terminal.
+	Input EventType = "i"
+	// Resize is a change of terminal size, with data "COLSxROWS".
+	Resize EventType = "r"
+	// Marker is a named point in the recording.
+	Marker EventType = "m"
+)
+
+// Event is one line of a recording after the header.
+type Event struct {
+	// Time is the number of seconds since the recording started.
+	Time float64
+	Type EventType
+	Data string
+}
+
+// MarshalJSON encodes e as a [time, type, data] array.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Time, e.Type, e.Data})
+}
+
+// UnmarshalJSON decodes e from a [time, type, data] array.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("asciicast: event has %d fields, want 3", len(fields))
+	}
+	if err := json.Unmarshal(fields[0], &e.Time); err != nil {
+		return fmt.Errorf("asciicast: event time: %w", err)
+	}
+	if err := json.Unmarshal(fields[1], &e.Type); err != nil {
+		return fmt.Errorf("asciicast: event type: %w", err)
+	}
+	if err := json.Unmarshal(fields[2], &e.Data); err != nil {
+		return fmt.Errorf("asciicast: event data: %w", err)
+	}
+	return nil
+}
+
+// ResizeData returns the data of a Resize event.
+func ResizeData(width, height int) string {
+	return strconv.Itoa(width) + "x" + strconv.Itoa(height)
+}
+
+// ParseResize parses the data of a Resize event.
+func ParseResize(data string) (width, height int, err error) {
+	if _, err := fmt.Sscanf(data, "%dx%d", &width, &height); err != nil {
+		return 0, 0, fmt.Errorf("asciicast: invalid resize %q", data)
+	}
+	return width, height, nil
+}
+
+// maxPending is how much output a Writer holds while waiting for the
+// terminal size before it writes the header with the default size.
+const maxPending = 64 << 10
+
+// Writer records a session to an io.Writer. It is safe for concurrent use.
+//
+// The header must be written first but the terminal size is often only
+// known after the program has started writing, so a Writer holds events
+// until the first Resize, which becomes the header's size, and writes them
+// then. Close writes anything still held.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	header  Header
+	started bool // header written
+	start   time.Time
+	pending []Event
+	size    int // bytes of data in pending
+	partial []byte
+	err     error
+}
+
+// NewWriter returns a Writer recording to w. Fields of header that are zero
+// are filled in: the version, the size once known, and the timestamp.
+func NewWriter(w io.Writer, header Header) *Writer {
+	rw := &Writer{w: w, header: header, start: time.Now()}
+	if rw.header.Version == 0 {
+		rw.header.Version = Version
+	}
+	if rw.header.Timestamp == 0 {
+		rw.header.Timestamp = rw.start.Unix()
+	}
+	return rw
+}
+
+// Output records data written to the terminal. A UTF-8 sequence split across
+// calls is held back until it is complete, since event data must be text.
+func (w *Writer) Output(p []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := append(w.partial, p...)
+	n := len(data) - utf8util.Incomplete(data)
+	w.partial = append([]byte(nil), data[n:]...)
+	if n > 0 {
+		w.add(Output, string(data[:n]))
+	}
+}
+
+// Input records data typed at the terminal.
+func (w *Writer) Input(p []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.add(Input, string(p))
+}
+
+// Resize records a change of terminal size. The first one before the header
+// is written sets the size in the header instead.
+func (w *Writer) Resize(width, height int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.started && w.header.Width == 0 {
+		w.header.Width, w.header.Height = width, height
+		w.flush()
+		return
+	}
+	w.add(Resize, ResizeData(width, height))
+}
+
+// Marker records a named point in the recording.
+func (w *Writer) Marker(label string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.add(Marker, label)
+}
+
+// Close writes the header and any held events, and returns the first error
+// from writing. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.add(Output, string(w.partial))
+		w.partial = nil
+	}
+	w.flush()
+	return w.err
+}
+
+// Err returns the first error from writing, if any.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// add records an event now. The caller must hold w.mu.
+func (w *Writer) add(typ EventType, data string) {
+	e := Event{Time: time.Since(w.start).Seconds(), Type: typ, Data: data}
+	if w.started {
+		w.write(e)
+		return
+	}
+	w.pending = append(w.pending, e)
+	w.size += len(data)
+	if w.size > maxPending {
+		w.flush()
+	}
+}
+
+// flush writes the header, with the default size if none is known yet, and
+// the events held until then. The caller must hold w.mu.
+func (w *Writer) flush() {
+	if !w.started {
+		if w.header.Width == 0 {
+			w.header.Width, w.header.Height = DefaultWidth, DefaultHeight
+		}
+		w.started = true
+		w.write(w.header)
+	}
+	for _, e := range w.pending {
+		w.write(e)
+	}
+	w.pending, w.size = nil, 0
+}
+
+// write writes v as a JSON line. The caller must hold w.mu.
+func (w *Writer) write(v any) {
+	if w.err != nil {
+		return
+	}
+	line, err := json.Marshal(v)
+	if err != nil {
+		w.err = err
+		return
+	}
+	_, w.err = w.w.Write(append(line, '\n'))
+}
+
+// Reader reads a recording.
+type Reader struct {
+	// Header is the recording's header.
+	Header Header
+
+	dec *json.Decoder
+}
+
+// NewReader reads the header of the recording in r and returns a Reader for
+// its events.
+func NewReader(r io.Reader) (*Reader, error) {
+	dec := json.NewDecoder(r)
+	var h Header
+	if err := dec.Decode(&h); err != nil {
+		return nil, fmt.Errorf("asciicast: reading header: %w", err)
+	}
+	if h.Version != Version {
+		return nil, fmt.Errorf("asciicast: unsupported version %d", h.Version)
+	}
+	return &Reader{Header: h, dec: dec}, nil
+}
+
+// Next returns the next event, or io.EOF at the end of the recording.
+func (r *Reader) Next() (Event, error) {
+	var e Event
+	if err := r.dec.Decode(&e); err != nil {
+		if err == io.EOF {
+			return Event{}, io.EOF
+		}
+		return Event{}, fmt.Errorf("asciicast: reading event: %w", err)
+	}
+	return e, nil
+}
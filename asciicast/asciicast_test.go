@@ -0,0 +1,106 @@
+package asciicast_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tmc/bubbweb/asciicast"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := asciicast.NewWriter(&buf, asciicast.Header{Title: "demo", Env: map[string]string{"TERM": "xterm-256color"}})
+	w.Output([]byte("before size\r\n"))
+	w.Resize(100, 30)
+	w.Input([]byte("q"))
+	w.Output([]byte("\x1b[31m\xe7\x95")) // 界, split across writes
+	w.Output([]byte("\x8c\x1b[m"))
+	w.Resize(120, 40)
+	w.Marker("done")
+	w.Output([]byte("\xe7"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := asciicast.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	h := r.Header
+	if h.Version != asciicast.Version || h.Width != 100 || h.Height != 30 || h.Title != "demo" ||
+		h.Env["TERM"] != "xterm-256color" || h.Timestamp == 0 {
+		t.Errorf("header = %+v", h)
+	}
+
+	want := []asciicast.Event{
+		{Type: asciicast.Output, Data: "before size\r\n"},
+		{Type: asciicast.Input, Data: "q"},
+		{Type: asciicast.Output, Data: "\x1b[31m"},
+		{Type: asciicast.Output, Data: "界\x1b[m"},
+		{Type: asciicast.Resize, Data: "120x40"},
+		{Type: asciicast.Marker, Data: "done"},
+		// Event data is JSON text, so a rune left incomplete at Close is
+		// replaced.
+		{Type: asciicast.Output, Data: "\ufffd"},
+	}
+	var last float64
+	for i, w := range want {
+		e, err := r.Next()
+		if err != nil {
+			t.Fatalf("event %d: %v", i, err)
+		}
+		if e.Type != w.Type || e.Data != w.Data {
+			t.Errorf("event %d = %q %q, want %q %q", i, e.Type, e.Data, w.Type, w.Data)
+		}
+		if e.Time < last {
+			t.Errorf("event %d at %v, before the previous one at %v", i, e.Time, last)
+		}
+		last = e.Time
+	}
+	if e, err := r.Next(); err != io.EOF {
+		t.Errorf("after the last event Next() = %+v, %v, want io.EOF", e, err)
+	}
+}
+
+func TestWriterDefaultSize(t *testing.T) {
+	var buf bytes.Buffer
+	w := asciicast.NewWriter(&buf, asciicast.Header{})
+	w.Output([]byte("hi"))
+	w.Close()
+
+	r, err := asciicast.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if r.Header.Width != asciicast.DefaultWidth || r.Header.Height != asciicast.DefaultHeight {
+		t.Errorf("size = %dx%d, want the default", r.Header.Width, r.Header.Height)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`{"version": 1, "width": 80, "height": 24}`,
+		"{\"version\": 2, \"width\": 80, \"height\": 24}\n[1, \"o\"]\n",
+	} {
+		r, err := asciicast.NewReader(strings.NewReader(input))
+		if err == nil {
+			_, err = r.Next()
+		}
+		if err == nil || err == io.EOF {
+			t.Errorf("reading %q: got %v, want an error", input, err)
+		}
+	}
+}
+
+func TestParseResize(t *testing.T) {
+	w, h, err := asciicast.ParseResize(asciicast.ResizeData(132, 43))
+	if w != 132 || h != 43 || err != nil {
+		t.Errorf("ParseResize(ResizeData(132, 43)) = %d, %d, %v", w, h, err)
+	}
+	if _, _, err := asciicast.ParseResize("wide"); err == nil {
+		t.Error("ParseResize(\"wide\") succeeded")
+	}
+}
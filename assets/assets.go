@@ -0,0 +1,183 @@
+// Package assets provides the browser side of bubbweb: a page template, the
+// bubbweb.js glue that connects xterm.js to a program, and the wasm_exec.js
+// runtime support file for WebAssembly builds.
+//
+// Serve them alongside a program compiled to bubbletea.wasm:
+//
+//	http.Handle("/", assets.Handler(assets.Page{Title: "My app"}))
+//	http.HandleFunc("/bubbletea.wasm", func(w http.ResponseWriter, r *http.Request) {
+//		http.ServeFile(w, r, "bubbletea.wasm")
+//	})
+//
+// The glue also works with a program served natively by bubbweb.Handler,
+// by setting Page.WebSocketURL or opening the page with ?ws=URL, and plays
+// recordings made with bubbweb.WithRecording when Page.CastURL is set. URLs
+// in the query must be on the page's own origin.
+package assets
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+//go:embed index.html.tmpl bubbweb.js wasm_exec.js
+var files embed.FS
+
+var indexTemplate = template.Must(template.ParseFS(files, "index.html.tmpl"))
+
+// Page configures the index.html page rendered from the template.
+type Page struct {
+	// Title is the page title. It defaults to "bubbweb".
+	Title string
+
+	// Theme is "dark", "light" or "auto" to follow the system preference,
+	// the default.
+	Theme string
+
+	// WasmURL is the URL of the WASM program. It defaults to "bubbletea.wasm".
+	WasmURL string
+
+	// WebSocketURL, if set, connects the page to a program served by
+	// bubbweb.Handler instead of loading WasmURL.
+	WebSocketURL string
+
+	// CastURL, if set, plays the asciicast v2 recording at this URL instead
+	// of running a program, as does opening the page with ?cast=URL for a
+	// URL on the page's own origin. Space pauses and resumes it, the arrow
+	// keys seek and + and - change the speed.
+	CastURL string
+
+	// Instance is the namespace the program was given with
+	// bubbweb.WithNamespace, if any.
+	Instance string
+
+	// LiveReload, if set, is the URL of a server-sent event stream; the page
+	// reloads when it receives a "reload" event. It replaces polling WasmURL
+	// for updates.
+	LiveReload string
+}
+
+func (p Page) withDefaults() Page {
+	if p.Title == "" {
+		p.Title = "bubbweb"
+	}
+	if p.Theme == "" {
+		p.Theme = "auto"
+	}
+	if p.WasmURL == "" {
+		p.WasmURL = "bubbletea.wasm"
+	}
+	return p
+}
+
+// Render writes the page to w.
+func (p Page) Render(w io.Writer) error {
+	return indexTemplate.Execute(w, p.withDefaults())
+}
+
+// Glue returns the bubbweb.js glue script.
+func Glue() []byte {
+	data, _ := files.ReadFile("bubbweb.js")
+	return data
+}
+
+// WasmExec returns the wasm_exec.js bundled with this package. It must match
+// the Go toolchain that built the WASM program; use the copy in
+// $(go env GOROOT)/lib/wasm when building with a different release.
+func WasmExec() []byte {
+	data, _ := files.ReadFile("wasm_exec.js")
+	return data
+}
+
+// FS returns a file system holding index.html rendered from p, bubbweb.js
+// and wasm_exec.js.
+func FS(p Page) (fs.FS, error) {
+	var buf bytes.Buffer
+	if err := p.Render(&buf); err != nil {
+		return nil, err
+	}
+	return &pageFS{files: files, index: buf.Bytes(), modTime: time.Now()}, nil
+}
+
+// Handler returns an http.Handler serving FS(p). The WASM program itself
+// must be served separately.
+func Handler(p Page) http.Handler {
+	fsys, err := FS(p)
+	if err != nil {
+		// The template is fixed and Page holds only strings, so rendering
+		// to memory cannot fail.
+		panic(err)
+	}
+	return http.FileServerFS(fsys)
+}
+
+// pageFS presents the rendered page as index.html in place of its template.
+type pageFS struct {
+	files   embed.FS
+	index   []byte
+	modTime time.Time
+}
+
+const (
+	indexName    = "index.html"
+	templateName = "index.html.tmpl"
+)
+
+func (f *pageFS) Open(name string) (fs.File, error) {
+	switch name {
+	case indexName:
+		return &memFile{Reader: bytes.NewReader(f.index), info: f.indexInfo()}, nil
+	case templateName:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.files.Open(name)
+}
+
+func (f *pageFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := f.files.ReadDir(name)
+	if err != nil || name != "." {
+		return entries, err
+	}
+	entries = slices.DeleteFunc(entries, func(e fs.DirEntry) bool {
+		return e.Name() == templateName
+	})
+	entries = append(entries, fs.FileInfoToDirEntry(f.indexInfo()))
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+func (f *pageFS) indexInfo() fs.FileInfo {
+	return memInfo{name: indexName, size: int64(len(f.index)), modTime: f.modTime}
+}
+
+// memFile is an in-memory fs.File.
+type memFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memInfo describes a memFile.
+type memInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return 0o444 }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return false }
+func (i memInfo) Sys() any           { return nil }
@@ -0,0 +1,821 @@
+// bubbweb.js connects an xterm.js terminal to a BubbleTea program, either one
+// compiled to WebAssembly with bubbweb or one served by bubbweb.Handler over a
+// WebSocket. It needs xterm.js, @xterm/addon-fit and, for WebAssembly,
+// wasm_exec.js to be loaded first.
+//
+//     bubbweb.start({ element: document.getElementById('terminal') });
+(() => {
+    const bubbweb = (globalThis.bubbweb ??= {});
+
+    // Terminal colors for each page theme
+    const themes = {
+        dark: { background: '#121212', foreground: '#f8f8f8', cursor: '#aeafad' },
+        light: { background: '#f8f8f8', foreground: '#2c3e50', cursor: '#16a085' }
+    };
+
+    // Mouse event constants to match Go enums
+    const MouseButton = {
+        None: 0,
+        Left: 1,
+        Middle: 2,
+        Right: 3,
+        WheelUp: 4,
+        WheelDown: 5,
+        WheelLeft: 6,
+        WheelRight: 7,
+        Backward: 8,
+        Forward: 9,
+        Button10: 10,
+        Button11: 11
+    };
+
+    const MouseAction = {
+        Press: 0,
+        Release: 1,
+        Motion: 2
+    };
+
+    // Resolve 'auto' to the system color scheme preference
+    function resolveTheme(theme) {
+        if (theme === 'dark' || theme === 'light') {
+            return theme;
+        }
+        return window.matchMedia('(prefers-color-scheme: dark)').matches ? 'dark' : 'light';
+    }
+
+    // Object holding the bridge functions of the program
+    function getBridge(instance) {
+        if (instance === null || instance === undefined) {
+            return globalThis;
+        }
+        return globalThis.bubbweb?.instances?.[instance] ?? {};
+    }
+
+    // Wait until the program has registered its bridge functions
+    function waitForBridge(instance) {
+        return new Promise((resolve) => {
+            const check = () => {
+                const bridge = getBridge(instance);
+                if (bridge.bubbletea_resize !== undefined &&
+                    bridge.bubbletea_onoutput !== undefined &&
+                    bridge.bubbletea_write !== undefined) {
+                    resolve(bridge);
+                    return;
+                }
+                setTimeout(check, 50);
+            };
+            check();
+        });
+    }
+
+    // Load and run a WASM program, retrying with exponential backoff
+    async function loadWasm(url, retryCount = 0) {
+        const maxRetries = 10;
+        const baseDelay = 1000;
+
+        try {
+            // Use ETag for caching
+            const eTag = localStorage.getItem('wasmETag') || '';
+            const headers = new Headers();
+            if (eTag) {
+                headers.append('If-None-Match', eTag);
+            }
+
+            let response = await fetch(url, { headers });
+
+            // Save new ETag if provided
+            const newETag = response.headers.get('ETag');
+            if (newETag) {
+                localStorage.setItem('wasmETag', newETag);
+            }
+
+            // Handle cached version
+            if (response.status === 304) {
+                response = await fetch(url);
+            }
+
+            const go = new Go();
+            const result = await WebAssembly.instantiateStreaming(response, go.importObject);
+            go.run(result.instance);
+        } catch (error) {
+            if (retryCount >= maxRetries) {
+                throw error;
+            }
+            const delay = baseDelay * Math.pow(1.5, retryCount) * (0.9 + Math.random() * 0.2);
+            console.error(`WASM load failed (${retryCount + 1}), retrying in ${Math.round(delay / 1000)}s:`, error);
+            await new Promise((resolve) => setTimeout(resolve, delay));
+            return loadWasm(url, retryCount + 1);
+        }
+    }
+
+    // Poll the WASM file and call onUpdate once its ETag changes
+    function watchForUpdates(url, interval, onUpdate) {
+        const timer = setInterval(async () => {
+            try {
+                const eTag = localStorage.getItem('wasmETag') || '';
+                const response = await fetch(url, {
+                    method: 'HEAD',
+                    headers: { 'If-None-Match': eTag },
+                    cache: 'no-cache'
+                });
+                const newETag = response.headers.get('ETag');
+                if (response.status === 200 && newETag && newETag !== eTag) {
+                    clearInterval(timer);
+                    onUpdate();
+                }
+            } catch (error) {
+                console.error('Update check failed:', error);
+            }
+        }, interval);
+    }
+
+    // Reload the page whenever the server sends a reload event, as the
+    // bubbweb serve development server does after each rebuild
+    function listenForReload(url) {
+        const events = new EventSource(url);
+        events.addEventListener('reload', () => window.location.reload());
+        events.addEventListener('builderror', (event) => {
+            console.error('Build failed:', JSON.parse(event.data));
+        });
+        return events;
+    }
+
+    // The fields of a KeyboardEvent, or an object like one, that
+    // bubbweb.KeyEvent mirrors. AltGr is reported by some systems as
+    // ctrl+alt, so both are cleared when it is held, as the WASM bridge does.
+    function keyFields(event) {
+        const altGraph = event.getModifierState?.('AltGraph') ?? false;
+        return {
+            key: event.key ?? '', code: event.code ?? '',
+            ctrl: !altGraph && !!event.ctrlKey, alt: !altGraph && !!event.altKey,
+            shift: !!event.shiftKey, meta: !!event.metaKey, repeat: !!event.repeat
+        };
+    }
+
+    // Keys bubbweb.KeyEvent.KeyMsg maps to a tea.KeyMsg. The socket bridge
+    // must answer bubbletea_key before the server sees the key, so this
+    // repeats the rules there: named keys, and single characters unless ctrl
+    // is held with one that forms no control character. Keys held with meta
+    // never map.
+    const namedKeys = new Set([
+        'Enter', 'Tab', 'Backspace', 'Escape', 'Delete', 'Insert',
+        'ArrowUp', 'ArrowDown', 'ArrowRight', 'ArrowLeft',
+        'Home', 'End', 'PageUp', 'PageDown',
+        ...Array.from({ length: 20 }, (_, i) => `F${i + 1}`)
+    ]);
+    function mappableKey({ key, code, ctrl, meta }) {
+        if (meta) return false;
+        if (namedKeys.has(key)) return true;
+        if ([...key].length !== 1) return false;
+        if (!ctrl) return true;
+        return /^[a-z]$/i.test(key) || /^Key[A-Z]$/.test(code) || ' @[\\]^_?'.includes(key);
+    }
+
+    // Connect to a program served by bubbweb.Handler, returning an object
+    // with the same functions the WASM bridge registers
+    function connectWebSocket(url) {
+        const wsURL = new URL(url, window.location.href);
+        wsURL.protocol = wsURL.protocol === 'https:' ? 'wss:' : 'ws:';
+        const socket = new WebSocket(wsURL);
+        socket.binaryType = 'arraybuffer';
+
+        // Hold messages and output until both ends are ready
+        const queued = [];
+        const pending = [];
+        let onOutput = null;
+        let onExit = null;
+        let links = { schemes: [], appSchemes: [] };
+        const send = (data) => {
+            if (socket.readyState === WebSocket.OPEN) {
+                socket.send(data);
+            } else if (socket.readyState === WebSocket.CONNECTING) {
+                queued.push(data);
+            }
+        };
+        const sendMessage = (msg) => send(JSON.stringify(msg));
+
+        let resolveExited, rejectExited;
+        const exited = new Promise((resolve, reject) => {
+            resolveExited = resolve;
+            rejectExited = reject;
+        });
+        exited.catch(() => {});
+
+        const bridge = {
+            bubbletea_write: (data) => send(typeof data === 'string' ? new TextEncoder().encode(data) : data),
+            bubbletea_paste: (data) => {
+                sendMessage({ type: 'paste', data });
+                return true;
+            },
+            bubbletea_focus: (focused) => {
+                sendMessage({ type: 'focus', focused });
+                return true;
+            },
+            bubbletea_link: (url) => {
+                let scheme;
+                try {
+                    scheme = new URL(url).protocol.slice(0, -1);
+                } catch {
+                    return false;
+                }
+                if (links.appSchemes?.includes(scheme)) {
+                    sendMessage({ type: 'link', url });
+                    return false;
+                }
+                return links.schemes?.includes(scheme) ?? false;
+            },
+            bubbletea_resize: (cols, rows) => sendMessage({ type: 'resize', cols, rows }),
+            bubbletea_mouse: (action, button, x, y, alt, ctrl, shift) =>
+                sendMessage({ type: 'mouse', action, button, x, y, alt, ctrl, shift }),
+            bubbletea_key: (event) => {
+                const key = keyFields(event);
+                if (!mappableKey(key)) {
+                    return false;
+                }
+                sendMessage({ type: 'key', ...key });
+                return true;
+            },
+            bubbletea_onoutput: (callback) => {
+                onOutput = callback;
+                pending.splice(0).forEach((data) => onOutput(data));
+            },
+            bubbletea_onexit: (callback) => { onExit = callback; },
+            bubbletea_quit: () => sendMessage({ type: 'quit' }),
+            bubbletea_kill: () => sendMessage({ type: 'kill' }),
+            bubbletea_exited: exited
+        };
+
+        // Settle the exit promise and remove the functions, like the WASM bridge
+        let done = false;
+        const finish = (exit) => {
+            if (done) return;
+            done = true;
+            onExit?.(exit);
+            for (const name of Object.keys(bridge)) {
+                if (name !== 'bubbletea_exited') delete bridge[name];
+            }
+            if (exit.reason === 'quit') {
+                resolveExited(exit);
+            } else {
+                rejectExited(Object.assign(new Error(exit.error ?? `program exited: ${exit.reason}`), exit));
+            }
+        };
+
+        socket.onopen = () => queued.splice(0).forEach((data) => socket.send(data));
+        socket.onmessage = (event) => {
+            if (typeof event.data === 'string') {
+                const msg = JSON.parse(event.data);
+                if (msg.type === 'links') {
+                    links = msg;
+                } else if (msg.type === 'exit') {
+                    finish({ reason: msg.reason, error: msg.error ?? null, model: msg.model });
+                }
+                return;
+            }
+            const data = new Uint8Array(event.data);
+            onOutput ? onOutput(data) : pending.push(data);
+        };
+        socket.onclose = () => finish({ reason: 'killed', error: 'connection closed', model: undefined });
+        return bridge;
+    }
+
+    // Parse an asciicast v2 recording into its header and events
+    function parseCast(text) {
+        const lines = text.split('\n').filter((line) => line.trim() !== '');
+        if (lines.length === 0) {
+            throw new Error('empty recording');
+        }
+        const header = JSON.parse(lines[0]);
+        if (header.version !== 2) {
+            throw new Error(`unsupported asciicast version ${header.version}`);
+        }
+        const events = lines.slice(1).map((line) => {
+            const [time, type, data] = JSON.parse(line);
+            return { time, type, data };
+        });
+
+        // Compress idle periods longer than the recording asks for
+        const limit = header.idle_time_limit;
+        if (limit > 0) {
+            let shift = 0;
+            let last = 0;
+            for (const event of events) {
+                const gap = event.time - last;
+                last = event.time;
+                if (gap > limit) {
+                    shift += gap - limit;
+                }
+                event.time -= shift;
+            }
+        }
+        return { header, events };
+    }
+
+    // Play an asciicast v2 recording. The player has the same output
+    // functions as a program's bridge, so a page shows a recording exactly as
+    // it shows the live program, and these controls:
+    //
+    //   play(), pause(), toggle()   start and stop playback
+    //   seek(seconds)               jump to a time, replaying output up to it
+    //   speed                       playback rate, 1 by default
+    //   currentTime, duration       position and length in seconds
+    //   paused, ended               playback state
+    //   markers                     [{time, label}] from the recording
+    //   onresize(cols, rows)        called when the recording resizes
+    //   onstatechange(player)       called on play, pause, seek and end
+    //
+    // Keys written to it control playback: space toggles, the arrow keys
+    // seek by five seconds, and + and - change the speed.
+    function createPlayer(text, options = {}) {
+        const { header, events } = parseCast(text);
+        const duration = events.length > 0 ? events[events.length - 1].time : 0;
+        const loop = options.loop ?? false;
+
+        let onOutput = null;
+        let timer = null;
+        let next = 0;          // index of the next event to play
+        let position = 0;      // seconds played when paused or at anchor
+        let anchor = null;     // performance.now() when position was current
+        let speed = options.speed ?? 1;
+        let ended = false;
+
+        let resolveExited;
+        const exited = new Promise((resolve) => { resolveExited = resolve; });
+
+        const emit = (data) => data && onOutput?.(new TextEncoder().encode(data));
+        const now = () => anchor === null ? position : position + (performance.now() - anchor) / 1000 * speed;
+        const changed = () => player.onstatechange?.(player);
+
+        // Play every event that is due, then wait for the next one
+        const tick = () => {
+            timer = null;
+            const t = now();
+            let output = '';
+            while (next < events.length && events[next].time <= t) {
+                const event = events[next++];
+                if (event.type === 'o') {
+                    output += event.data;
+                } else if (event.type === 'r') {
+                    emit(output);
+                    output = '';
+                    const [cols, rows] = event.data.split('x').map(Number);
+                    player.onresize?.(cols, rows);
+                }
+            }
+            emit(output);
+
+            if (next >= events.length) {
+                // A recording with no length would replay itself forever
+                // without ever yielding, so it ends instead.
+                if (loop && duration > 0) {
+                    seek(0);
+                    return;
+                }
+                position = duration;
+                anchor = null;
+                ended = true;
+                changed();
+                resolveExited({ reason: 'quit', error: null, model: undefined });
+                return;
+            }
+            const delay = (events[next].time - t) / speed * 1000;
+            timer = setTimeout(tick, Math.max(delay, 0));
+        };
+
+        const play = () => {
+            if (anchor !== null) return;
+            if (ended) {
+                seek(0);
+            }
+            anchor = performance.now();
+            tick();
+            changed();
+        };
+
+        const pause = () => {
+            if (anchor === null) return;
+            position = now();
+            anchor = null;
+            clearTimeout(timer);
+            timer = null;
+            changed();
+        };
+
+        // Replay from a reset terminal so that seeking backwards works too
+        const seek = (seconds) => {
+            const playing = anchor !== null;
+            clearTimeout(timer);
+            timer = null;
+            position = Math.min(Math.max(seconds, 0), duration);
+            anchor = playing ? performance.now() : null;
+            ended = false;
+
+            let output = '\x1bc';
+            let size = [header.width, header.height];
+            next = 0;
+            while (next < events.length && events[next].time <= position) {
+                const event = events[next++];
+                if (event.type === 'o') {
+                    output += event.data;
+                } else if (event.type === 'r') {
+                    size = event.data.split('x').map(Number);
+                }
+            }
+            player.onresize?.(size[0], size[1]);
+            emit(output);
+            if (playing) {
+                tick();
+            }
+            changed();
+        };
+
+        const player = {
+            header,
+            markers: events.filter((e) => e.type === 'm').map((e) => ({ time: e.time, label: e.data })),
+            play,
+            pause,
+            toggle: () => (anchor === null ? play() : pause()),
+            seek,
+            get currentTime() { return now(); },
+            get duration() { return duration; },
+            get paused() { return anchor === null; },
+            get ended() { return ended; },
+            get speed() { return speed; },
+            set speed(value) {
+                position = now();
+                if (anchor !== null) anchor = performance.now();
+                speed = value;
+                if (timer !== null) {
+                    clearTimeout(timer);
+                    tick();
+                }
+                changed();
+            },
+            onresize: null,
+            onstatechange: null,
+
+            // Bridge functions
+            bubbletea_onoutput: (callback) => {
+                onOutput = callback;
+                if (options.autoplay ?? true) {
+                    play();
+                }
+            },
+            bubbletea_write: (data) => {
+                const key = typeof data === 'string' ? data : new TextDecoder().decode(data);
+                switch (key) {
+                    case ' ': player.toggle(); break;
+                    case '\x1b[C': seek(now() + 5); break;
+                    case '\x1b[D': seek(now() - 5); break;
+                    case '+': case '=': player.speed = speed * 2; break;
+                    case '-': player.speed = speed / 2; break;
+                }
+            },
+            bubbletea_resize: () => {},
+            bubbletea_link: (url) => /^https?:/i.test(url),
+            bubbletea_exited: exited
+        };
+        return player;
+    }
+
+    // Name of a key press in browserKeys, such as 'ctrl+w' or 'ctrl+shift+t'.
+    // AltGr types characters even where it is reported as ctrl+alt, so
+    // neither is part of the name while it is held.
+    function shortcutName(event) {
+        const altGraph = event.getModifierState?.('AltGraph') ?? false;
+        const mods = [];
+        if (event.ctrlKey && !altGraph) mods.push('ctrl');
+        if (event.altKey && !altGraph) mods.push('alt');
+        if (event.shiftKey) mods.push('shift');
+        if (event.metaKey) mods.push('meta');
+        return [...mods, event.key.toLowerCase()].join('+');
+    }
+
+    // Deliver key presses through bubbletea_key. Keys the program cannot
+    // receive go to xterm.js as before, and the shortcuts listed in
+    // browserKeys are left to the browser.
+    function handleKeys(term, bridge, browserKeys) {
+        if (browserKeys.length === 0) {
+            // Lets fullscreen pages capture keys the browser reserves, such
+            // as ctrl+w and ctrl+t
+            navigator.keyboard?.lock?.().catch(() => {});
+        }
+        const mac = /Mac|iPhone|iPad/.test(navigator.platform);
+        term.attachCustomKeyEventHandler((event) => {
+            if (event.type !== 'keydown' || event.isComposing || event.keyCode === 229) {
+                return true;
+            }
+            if (browserKeys.includes(shortcutName(event))) {
+                return false;
+            }
+            // Option types characters on macOS, as xterm.js assumes by default
+            let key = event;
+            if (mac && event.altKey && !event.ctrlKey && [...event.key].length === 1) {
+                key = {
+                    key: event.key, code: event.code, shiftKey: event.shiftKey,
+                    metaKey: event.metaKey, repeat: event.repeat
+                };
+            }
+            if (!bridge.bubbletea_key?.(key)) {
+                return true;
+            }
+            // Also stops the keypress and input events that would type the key again
+            event.preventDefault();
+            return false;
+        });
+    }
+
+    // Create a terminal in element and connect it to bridge
+    function attach(element, bridge, options) {
+        // Open OSC 8 hyperlinks in a new tab if the program allows it
+        const linkHandler = {
+            allowNonHttpProtocols: true,
+            activate: (event, url) => {
+                if (bridge.bubbletea_link?.(url)) {
+                    window.open(url, '_blank', 'noopener');
+                }
+            }
+        };
+        const term = new Terminal({ theme: themes[resolveTheme(options.theme)], linkHandler });
+        const fitAddon = new FitAddon.FitAddon();
+        term.loadAddon(fitAddon);
+        term.open(element);
+
+        // A recording is shown at the size it was made at
+        if (bridge.header !== undefined) {
+            bridge.onresize = (cols, rows) => term.resize(cols, rows);
+            term.resize(bridge.header.width, bridge.header.height);
+        } else {
+            fitAddon.fit();
+            window.addEventListener('resize', () => fitAddon.fit());
+        }
+
+        // Follow system theme changes
+        if (options.theme === 'auto') {
+            window.matchMedia('(prefers-color-scheme: dark)').addEventListener('change', (e) => {
+                term.options.theme = themes[e.matches ? 'dark' : 'light'];
+            });
+        }
+
+        // Handle terminal resize
+        term.onResize((size) => bridge.bubbletea_resize?.(size.cols, size.rows));
+        bridge.bubbletea_resize(term.cols, term.rows);
+        term.focus();
+
+        // Show the window title the program sets as the page's, until it
+        // exits, unless the page handles titles itself
+        let onTitle = options.onTitle;
+        if (!onTitle) {
+            const pageTitle = document.title;
+            onTitle = (title) => { document.title = title || pageTitle; };
+            const restore = () => { document.title = pageTitle; };
+            bridge.bubbletea_exited.then(restore, restore);
+        }
+        if (bridge.bubbletea_ontitle) {
+            bridge.bubbletea_ontitle(onTitle);
+        } else {
+            term.onTitleChange(onTitle);
+        }
+
+        // Write bubbletea output to xterm as soon as it is flushed
+        bridge.bubbletea_onoutput((data) => term.write(data));
+
+        // Write xterm input to bubbletea
+        term.onData((data) => {
+            // xterm.js reports its own focus changes once the program enables
+            // focus reporting; bubbletea_focus reports them instead, below
+            if (bridge.bubbletea_focus && (data === '\x1b[I' || data === '\x1b[O')) {
+                return;
+            }
+            bridge.bubbletea_write?.(data);
+        });
+        term.onBinary((data) => bridge.bubbletea_write?.(Uint8Array.from(data, (c) => c.charCodeAt(0))));
+
+        // Deliver pastes as a whole, so the program can tell them from typing
+        if (bridge.bubbletea_paste) {
+            element.addEventListener('paste', (event) => {
+                const text = event.clipboardData?.getData('text/plain');
+                if (!text) return;
+                event.preventDefault();
+                event.stopPropagation();
+                if (!bridge.bubbletea_paste?.(text)) {
+                    console.warn(`Paste of ${text.length} characters was not accepted`);
+                }
+            }, { capture: true });
+        }
+
+        // Report focus changes, counting a hidden page as unfocused
+        if (bridge.bubbletea_focus) {
+            let focused = document.activeElement === term.textarea;
+            const update = () => {
+                const now = document.visibilityState === 'visible' &&
+                    document.activeElement === term.textarea && document.hasFocus();
+                if (now !== focused) {
+                    focused = now;
+                    bridge.bubbletea_focus?.(now);
+                }
+            };
+            term.textarea?.addEventListener('focus', update);
+            term.textarea?.addEventListener('blur', update);
+            document.addEventListener('visibilitychange', update);
+            window.addEventListener('focus', update);
+            window.addEventListener('blur', update);
+        }
+
+        // Send key presses as structured events when the program accepts
+        // them, so it gets exactly the key the browser saw
+        if (options.keys && bridge.bubbletea_key) {
+            handleKeys(term, bridge, options.browserKeys);
+        }
+
+        // Offer a restart once the program exits; the bridge functions are gone afterwards
+        bridge.bubbletea_exited.then(
+            (exit) => options.onExit?.(exit),
+            (err) => options.onExit?.(err)
+        );
+        if (options.restartOnExit) {
+            const offerRestart = (status) => {
+                term.write(`\r\n[program ${status}, press any key to restart]\r\n`);
+                term.onData(() => window.location.reload());
+            };
+            bridge.bubbletea_exited.then(
+                () => offerRestart('exited'),
+                (err) => offerRestart(`${err.reason}: ${err.message}`)
+            );
+        }
+
+        // Get cell coordinates from pixel coordinates
+        function getCellCoordinates(pixelX, pixelY) {
+            const rect = element.getBoundingClientRect();
+            const cellWidth = rect.width / term.cols;
+            const cellHeight = rect.height / term.rows;
+            return {
+                x: Math.floor((pixelX - rect.left) / cellWidth),
+                y: Math.floor((pixelY - rect.top) / cellHeight)
+            };
+        }
+
+        // Convert browser mouse button to our enum
+        function getMouseButton(button) {
+            switch (button) {
+                case 0: return MouseButton.Left;
+                case 1: return MouseButton.Middle;
+                case 2: return MouseButton.Right;
+                default: return MouseButton.None;
+            }
+        }
+
+        function sendMouse(action, button, event) {
+            const coords = getCellCoordinates(event.clientX, event.clientY);
+            bridge.bubbletea_mouse?.(action, button, coords.x, coords.y,
+                event.altKey, event.ctrlKey, event.shiftKey);
+        }
+
+        element.addEventListener('mousedown', (event) => {
+            sendMouse(MouseAction.Press, getMouseButton(event.button), event);
+            event.preventDefault();
+        });
+
+        element.addEventListener('mouseup', (event) => {
+            sendMouse(MouseAction.Release, getMouseButton(event.button), event);
+            event.preventDefault();
+        });
+
+        element.addEventListener('mousemove', (event) => {
+            let button = MouseButton.None;
+            if (event.buttons & 1) {
+                button = MouseButton.Left;
+            } else if (event.buttons & 4) {
+                button = MouseButton.Middle;
+            } else if (event.buttons & 2) {
+                button = MouseButton.Right;
+            }
+            sendMouse(MouseAction.Motion, button, event);
+        });
+
+        element.addEventListener('wheel', (event) => {
+            let button = MouseButton.None;
+            if (event.deltaY !== 0) {
+                button = event.deltaY < 0 ? MouseButton.WheelUp : MouseButton.WheelDown;
+            } else if (event.deltaX !== 0) {
+                button = event.deltaX < 0 ? MouseButton.WheelLeft : MouseButton.WheelRight;
+            }
+            sendMouse(MouseAction.Press, button, event);
+        }, { passive: true });
+
+        return term;
+    }
+
+    // Start a program and attach a terminal to it. Options:
+    //
+    //   element        element to open the terminal in (required)
+    //   wasmURL        WASM program to load, or null if it is already running
+    //                  (default 'bubbletea.wasm')
+    //   websocketURL   bubbweb.Handler to connect to instead of loading WASM
+    //   castURL        asciicast v2 recording to play instead of a program;
+    //                  the resolved bridge is then the player
+    //   autoplay       start playing the recording at once (default true)
+    //   loop           play the recording again once it ends (default false)
+    //   speed          playback rate of the recording (default 1)
+    //   instance       namespace passed to bubbweb.WithNamespace, if any
+    //   keys           send key presses to the program as structured events
+    //                  with bubbletea_key instead of escape sequences (default true)
+    //   browserKeys    shortcuts, such as ['ctrl+w', 'ctrl+shift+t'], to leave
+    //                  to the browser instead of sending to the program
+    //                  (default [])
+    //   theme          'dark', 'light' or 'auto' (default 'auto')
+    //   updateInterval milliseconds between checks for a new WASM file, or 0
+    //                  to disable (default 5000)
+    //   onUpdate       called once a new WASM file is available
+    //   liveReloadURL  server-sent event stream whose "reload" events reload
+    //                  the page, replacing the update check
+    //   onTitle        called with each window title the program sets, instead
+    //                  of showing it as the page's title
+    //   onExit         called with the exit object or error when the program exits
+    //   restartOnExit  offer to reload the page when the program exits (default true)
+    //
+    // It resolves with the xterm.js Terminal and the bridge object.
+    bubbweb.start = async function start(options) {
+        options = {
+            wasmURL: 'bubbletea.wasm',
+            websocketURL: null,
+            castURL: null,
+            autoplay: true,
+            loop: false,
+            speed: 1,
+            instance: null,
+            keys: true,
+            browserKeys: [],
+            theme: 'auto',
+            updateInterval: 5000,
+            onUpdate: null,
+            liveReloadURL: null,
+            onTitle: null,
+            onExit: null,
+            restartOnExit: true,
+            ...options
+        };
+
+        if (options.liveReloadURL) {
+            listenForReload(options.liveReloadURL);
+        }
+
+        let bridge;
+        if (options.castURL) {
+            const response = await fetch(options.castURL);
+            if (!response.ok) {
+                throw new Error(`fetching ${options.castURL}: ${response.status}`);
+            }
+            bridge = createPlayer(await response.text(), options);
+            options.restartOnExit = false;
+        } else if (options.websocketURL) {
+            bridge = connectWebSocket(options.websocketURL);
+        } else {
+            if (options.wasmURL) {
+                await loadWasm(options.wasmURL);
+                if (options.updateInterval > 0 && options.onUpdate && !options.liveReloadURL) {
+                    watchForUpdates(options.wasmURL, options.updateInterval, options.onUpdate);
+                }
+            }
+            bridge = await waitForBridge(options.instance);
+        }
+
+        const term = attach(options.element, bridge, options);
+        return { term, bridge };
+    };
+
+    // Download the session recorded with bubbweb.WithRecording as an
+    // asciicast file
+    bubbweb.downloadRecording = function downloadRecording(bridge, filename = 'session.cast') {
+        if (!bridge.bubbletea_recording) {
+            throw new Error('program is not recording');
+        }
+        const url = URL.createObjectURL(bridge.bubbletea_recording());
+        const link = document.createElement('a');
+        link.href = url;
+        link.download = filename;
+        link.click();
+        setTimeout(() => URL.revokeObjectURL(url), 0);
+    };
+
+    // Download the program's screen as an HTML or SVG file, drawn with the
+    // given xterm.js theme, by default the page's current one
+    bubbweb.downloadScreenshot = function downloadScreenshot(bridge, format = 'svg', theme = null) {
+        if (!bridge.bubbletea_screenshot) {
+            throw new Error('screenshots are not available');
+        }
+        theme ??= themes[resolveTheme('auto')];
+        const type = format === 'svg' ? 'image/svg+xml' : 'text/html';
+        const blob = new Blob([bridge.bubbletea_screenshot(format, theme)], { type });
+        const url = URL.createObjectURL(blob);
+        const link = document.createElement('a');
+        link.href = url;
+        link.download = `screenshot.${format}`;
+        link.click();
+        setTimeout(() => URL.revokeObjectURL(url), 0);
+    };
+
+    bubbweb.themes = themes;
+    bubbweb.connectWebSocket = connectWebSocket;
+    bubbweb.createPlayer = createPlayer;
+})();
@@ -0,0 +1,221 @@
+//go:build js
+// +build js
+
+package bubbweb
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb/vt"
+)
+
+// bridge is the set of JavaScript functions through which a page drives a
+// program. It keeps track of what it registered so it can remove and release
+// everything once the program exits.
+type bridge struct {
+	namespace string
+	object    js.Value
+	funcs     map[string]js.Func
+	onExit    js.Value
+	resolve   js.Value
+	reject    js.Value
+}
+
+func newBridge(namespace string) *bridge {
+	b := &bridge{
+		namespace: namespace,
+		object:    bridgeObject(namespace),
+		funcs:     make(map[string]js.Func),
+		onExit:    js.Null(),
+	}
+
+	// The promise is not a function, so it outlives release and pages can
+	// still await it after the program has exited.
+	var promise js.Value
+	promise, b.resolve, b.reject = newExitPromise()
+	b.object.Set("bubbletea_exited", promise)
+	return b
+}
+
+// register exposes fn to JavaScript as name.
+func (b *bridge) register(name string, fn func(this js.Value, args []js.Value) interface{}) {
+	f := js.FuncOf(fn)
+	b.funcs[name] = f
+	b.object.Set(name, f)
+}
+
+// reportExit settles the bubbletea_exited promise and calls the function
+// registered with bubbletea_onexit, if any, with an object describing how the
+// program exited: its ExitReason, error message and model summary.
+func (b *bridge) reportExit(model tea.Model, err error) {
+	reason := exitReason(model, err)
+	exit := js.Global().Get("Object").New()
+	exit.Set("reason", string(reason))
+	exit.Set("error", js.Null())
+	if err != nil {
+		exit.Set("error", err.Error())
+	}
+	if s, ok := model.(ExitSummarizer); ok {
+		exit.Set("model", jsonValue(s.ExitSummary()))
+	}
+
+	if b.onExit.Type() == js.TypeFunction {
+		b.onExit.Invoke(exit)
+	}
+
+	if reason == ExitQuit {
+		b.resolve.Invoke(exit)
+		return
+	}
+	msg := "program exited: " + string(reason)
+	if err != nil {
+		msg = err.Error()
+	}
+	reject := js.Global().Get("Error").New(msg)
+	reject.Set("reason", exit.Get("reason"))
+	reject.Set("model", exit.Get("model"))
+	b.reject.Invoke(reject)
+}
+
+// newExitPromise returns a promise for the program's exit along with the
+// functions that settle it. The promise counts as handled, so pages that
+// ignore it do not see unhandled rejections when a program is killed.
+func newExitPromise() (promise, resolve, reject js.Value) {
+	executor := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		resolve, reject = args[0], args[1]
+		return nil
+	})
+	defer executor.Release()
+
+	promise = js.Global().Get("Promise").New(executor)
+	promise.Call("catch", js.Global().Get("Function").New())
+	return promise, resolve, reject
+}
+
+// jsonValue converts v to a JavaScript value by way of JSON.
+// It returns undefined if v cannot be encoded.
+func jsonValue(v any) js.Value {
+	data, err := json.Marshal(v)
+	if err != nil {
+		js.Global().Get("console").Call("error", "bubbweb: encoding exit summary:", err.Error())
+		return js.Undefined()
+	}
+	return js.Global().Get("JSON").Call("parse", string(data))
+}
+
+// release unregisters and releases every function registered on the bridge.
+// Afterwards JavaScript can no longer call into the program.
+func (b *bridge) release() {
+	for name, f := range b.funcs {
+		b.object.Delete(name)
+		f.Release()
+	}
+	b.funcs = nil
+
+	if b.namespace != "" {
+		js.Global().Get("bubbweb").Get("instances").Delete(b.namespace)
+	}
+}
+
+// bridgeObject returns the JavaScript object that holds the bridge functions
+// of the program in namespace, creating globalThis.bubbweb.instances[namespace]
+// as needed. The empty namespace is the global object itself.
+func bridgeObject(namespace string) js.Value {
+	global := js.Global()
+	if namespace == "" {
+		return global
+	}
+
+	root := global.Get("bubbweb")
+	if root.Type() != js.TypeObject {
+		root = global.Get("Object").New()
+		global.Set("bubbweb", root)
+	}
+	instances := root.Get("instances")
+	if instances.Type() != js.TypeObject {
+		instances = global.Get("Object").New()
+		root.Set("instances", instances)
+	}
+	obj := instances.Get(namespace)
+	if obj.Type() != js.TypeObject {
+		obj = global.Get("Object").New()
+		instances.Set(namespace, obj)
+	}
+	return obj
+}
+
+// bytesToJS copies p into a new Uint8Array.
+func bytesToJS(p []byte) js.Value {
+	arr := js.Global().Get("Uint8Array").New(len(p))
+	js.CopyBytesToJS(arr, p)
+	return arr
+}
+
+// bytesFromJS returns the bytes of v, which is either a Uint8Array or a
+// string to be encoded as UTF-8.
+func bytesFromJS(v js.Value) []byte {
+	if v.InstanceOf(js.Global().Get("Uint8Array")) {
+		p := make([]byte, v.Length())
+		js.CopyBytesToGo(p, v)
+		return p
+	}
+	return []byte(v.String())
+}
+
+// keyEventFromJS reads a KeyboardEvent, or an object with the same fields.
+// AltGr is reported by some systems as ctrl+alt, which would turn the
+// characters it types into control keys, so both are cleared when it is held.
+func keyEventFromJS(v js.Value) KeyEvent {
+	str := func(name string) string {
+		if f := v.Get(name); f.Type() == js.TypeString {
+			return f.String()
+		}
+		return ""
+	}
+	e := KeyEvent{
+		Key:    str("key"),
+		Code:   str("code"),
+		Ctrl:   v.Get("ctrlKey").Truthy(),
+		Alt:    v.Get("altKey").Truthy(),
+		Shift:  v.Get("shiftKey").Truthy(),
+		Meta:   v.Get("metaKey").Truthy(),
+		Repeat: v.Get("repeat").Truthy(),
+	}
+	if v.Get("getModifierState").Type() == js.TypeFunction && v.Call("getModifierState", "AltGraph").Truthy() {
+		e.Ctrl, e.Alt = false, false
+	}
+	return e
+}
+
+// ansiNames are the xterm.js theme keys of the 16 ANSI colors, in order.
+var ansiNames = [16]string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"brightBlack", "brightRed", "brightGreen", "brightYellow",
+	"brightBlue", "brightMagenta", "brightCyan", "brightWhite",
+}
+
+// paletteFromJS returns the palette for theme, which is "dark", "light" or
+// an xterm.js theme object overriding the dark palette's colors.
+func paletteFromJS(theme js.Value) vt.Palette {
+	switch {
+	case theme.Type() == js.TypeString && theme.String() == "light":
+		return vt.LightPalette
+	case theme.Type() != js.TypeObject:
+		return vt.DarkPalette
+	}
+
+	p := vt.DarkPalette
+	set := func(dst *string, key string) {
+		if v := theme.Get(key); v.Type() == js.TypeString {
+			*dst = v.String()
+		}
+	}
+	set(&p.Foreground, "foreground")
+	set(&p.Background, "background")
+	for i, name := range ansiNames {
+		set(&p.ANSI[i], name)
+	}
+	return p
+}
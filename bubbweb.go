@@ -4,71 +4,168 @@
 package bubbweb
 
 import (
-	"bytes"
 	"fmt"
 	"syscall/js"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// MinReadBuffer is a custom buffer for handling bubbletea's input expectations in WASM
-type MinReadBuffer struct {
-	buf *bytes.Buffer
-}
+// defaultMouse is the mouse mode of DefaultConfig.
+const defaultMouse = MouseCellMotion
+
+// New creates a new BubbleTea program configured for WASM
+func New(model tea.Model, opts ...Option) *Program {
+	cfg := newConfig(opts)
+	prog := newPipedProgram(model, cfg)
+	output := newPusher(prog.output)
+
+	// Register the bridge functions globally, or on the program's namespace
+	bridge := newBridge(cfg.Namespace)
+
+	// Route OSC 52 clipboard requests to the browser's clipboard
+	prog.handleClipboard()
+
+	// Show notifications the program requests with OSC 9 and OSC 777
+	prog.handleNotifications()
 
-// Read from the buffer, blocking until data is available
-func (b *MinReadBuffer) Read(p []byte) (n int, err error) {
-	for b.buf.Len() == 0 {
-		time.Sleep(100 * time.Millisecond)
+	// Tear the bridge down once the program exits
+	prog.onExit = func(model tea.Model, err error) {
+		output.close()
+		bridge.reportExit(model, err)
+		bridge.release()
 	}
-	return b.buf.Read(p)
-}
 
-// Write to the buffer
-func (b *MinReadBuffer) Write(p []byte) (n int, err error) {
-	return b.buf.Write(p)
-}
+	// Register write function in WASM
+	bridge.register("bubbletea_write", func(this js.Value, args []js.Value) interface{} {
+		prog.Input(bytesFromJS(args[0]))
+		return nil
+	})
 
-// NewProgram creates a new BubbleTea program configured for WASM
-func NewProgram(model tea.Model, options ...tea.ProgramOption) *tea.Program {
-	fromJs := &MinReadBuffer{buf: bytes.NewBuffer(nil)}
-	fromGo := bytes.NewBuffer(nil)
+	// Register read function in WASM, unless the page must subscribe
+	if cfg.Polling {
+		bridge.register("bubbletea_read", func(this js.Value, args []js.Value) interface{} {
+			return bytesToJS(prog.output.Drain())
+		})
+	}
+
+	// Register recording function in WASM, if the session is recorded
+	if cfg.Record {
+		bridge.register("bubbletea_recording", func(this js.Value, args []js.Value) interface{} {
+			return js.Global().Get("Blob").New(
+				[]interface{}{bytesToJS(prog.Recording())},
+				map[string]interface{}{"type": "application/x-asciicast"},
+			)
+		})
+	}
 
-	// Combine default options with user-provided options
-	defaultOptions := []tea.ProgramOption{
-		tea.WithInput(fromJs),
-		tea.WithOutput(fromGo),
-		tea.WithMouseCellMotion(),
+	// Register screenshot function in WASM
+	bridge.register("bubbletea_screenshot", func(this js.Value, args []js.Value) interface{} {
+		format, theme := "html", js.Undefined()
+		if len(args) > 0 && args[0].Type() == js.TypeString {
+			format = args[0].String()
+		}
+		if len(args) > 1 {
+			theme = args[1]
+		}
+		palette := paletteFromJS(theme)
+		if format == "svg" {
+			return string(prog.Screen().SVG(palette))
+		}
+		return string(prog.Screen().HTML(palette))
+	})
+
+	// Register output subscription function in WASM
+	bridge.register("bubbletea_onoutput", func(this js.Value, args []js.Value) interface{} {
+		callback := js.Null()
+		if len(args) > 0 {
+			callback = args[0]
+		}
+		output.subscribe(callback)
+		return nil
+	})
+
+	// Register title subscription function in WASM. The callback is called
+	// with each window title the program sets, starting with the current one.
+	onTitle := js.Null()
+	setTitle := func(title string) {
+		if onTitle.Type() == js.TypeFunction {
+			onTitle.Invoke(title)
+		}
 	}
-	allOptions := append(defaultOptions, options...)
+	prog.handleOSC(0, setTitle)
+	prog.handleOSC(2, setTitle)
+	bridge.register("bubbletea_ontitle", func(this js.Value, args []js.Value) interface{} {
+		onTitle = js.Null()
+		if len(args) > 0 {
+			onTitle = args[0]
+		}
+		if title := prog.Screen().Title(); title != "" {
+			setTitle(title)
+		}
+		return nil
+	})
 
-	prog := tea.NewProgram(model, allOptions...)
+	// Register exit subscription function in WASM
+	bridge.register("bubbletea_onexit", func(this js.Value, args []js.Value) interface{} {
+		bridge.onExit = js.Null()
+		if len(args) > 0 {
+			bridge.onExit = args[0]
+		}
+		return nil
+	})
 
-	// Register write function in WASM
-	js.Global().Set("bubbletea_write", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		fromJs.Write([]byte(args[0].String()))
+	// Register quit function in WASM
+	bridge.register("bubbletea_quit", func(this js.Value, args []js.Value) interface{} {
+		go prog.Quit()
 		return nil
-	}))
+	})
 
-	// Register read function in WASM
-	js.Global().Set("bubbletea_read", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		b := make([]byte, fromGo.Len())
-		_, _ = fromGo.Read(b)
-		fromGo.Reset()
-		return string(b)
-	}))
+	// Register kill function in WASM
+	bridge.register("bubbletea_kill", func(this js.Value, args []js.Value) interface{} {
+		go prog.Kill()
+		return nil
+	})
+
+	// Register key event function in WASM
+	bridge.register("bubbletea_key", func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 1 || args[0].Type() != js.TypeObject {
+			return false
+		}
+		return prog.Key(keyEventFromJS(args[0]))
+	})
+
+	// Register paste function in WASM
+	bridge.register("bubbletea_paste", func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 1 || args[0].Type() != js.TypeString {
+			return false
+		}
+		return prog.Paste(args[0].String())
+	})
+
+	// Register focus function in WASM
+	bridge.register("bubbletea_focus", func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 1 {
+			return false
+		}
+		return prog.Focus(args[0].Truthy())
+	})
+
+	// Register link function in WASM
+	bridge.register("bubbletea_link", func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 1 || args[0].Type() != js.TypeString {
+			return false
+		}
+		return prog.Link(args[0].String())
+	})
 
 	// Register resize function in WASM
-	js.Global().Set("bubbletea_resize", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		width := args[0].Int()
-		height := args[1].Int()
-		prog.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	bridge.register("bubbletea_resize", func(this js.Value, args []js.Value) interface{} {
+		prog.Resize(args[0].Int(), args[1].Int())
 		return nil
-	}))
+	})
 
 	// Register mouse event function in WASM
-	js.Global().Set("bubbletea_mouse", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	bridge.register("bubbletea_mouse", func(this js.Value, args []js.Value) interface{} {
 		if len(args) < 7 {
 			fmt.Println("Invalid mouse event arguments")
 			return nil
@@ -89,7 +186,7 @@ func NewProgram(model tea.Model, options ...tea.ProgramOption) *tea.Program {
 			shift = args[6].Bool()
 		}
 
-		msg := tea.MouseMsg{
+		prog.Mouse(tea.MouseMsg{
 			Action: eventType,
 			Button: button,
 			X:      x,
@@ -97,11 +194,10 @@ func NewProgram(model tea.Model, options ...tea.ProgramOption) *tea.Program {
 			Alt:    alt,
 			Ctrl:   ctrl,
 			Shift:  shift,
-		}
-		prog.Send(msg)
+		})
 
 		return nil
-	}))
+	})
 
 	return prog
 }
@@ -4,7 +4,50 @@
 package bubbweb
 
 import (
+	"os"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
 )
 
-var NewProgram = tea.NewProgram
+// defaultMouse is the mouse mode of DefaultConfig.
+const defaultMouse = MouseNone
+
+// New creates a new BubbleTea program using the terminal for input and output.
+//
+// When the session is recorded, only the program's output and the initial
+// terminal size are, since the terminal's input and resizes go to the
+// program directly.
+func New(model tea.Model, opts ...Option) *Program {
+	cfg := newConfig(opts)
+	rec := newRecording(cfg, false)
+
+	p := &Program{
+		config:    cfg,
+		recording: rec,
+		out:       os.Stdout,
+	}
+	var options []tea.ProgramOption
+	if rec != nil {
+		if width, height, err := term.GetSize(os.Stdout.Fd()); err == nil {
+			rec.Resize(width, height)
+		}
+		p.out = recordingFile{File: os.Stdout, rec: rec}
+		options = append(options, tea.WithOutput(p.out))
+	}
+	p.Program = tea.NewProgram(model, append(options, cfg.programOptions()...)...)
+	p.installFilter()
+	return p
+}
+
+// recordingFile records what the program writes to its terminal. It keeps
+// the rest of the *os.File so the program still recognizes the terminal.
+type recordingFile struct {
+	*os.File
+	rec *recording
+}
+
+func (f recordingFile) Write(p []byte) (int, error) {
+	f.rec.Output(p)
+	return f.File.Write(p)
+}
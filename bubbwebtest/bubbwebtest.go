@@ -0,0 +1,283 @@
+// Package bubbwebtest runs BubbleTea models headlessly for tests, through the
+// same input, output, resize and mouse plumbing bubbweb uses in the browser.
+//
+//	func TestQuit(t *testing.T) {
+//		h := bubbwebtest.New(t, newModel())
+//		h.WaitForString("Press q to quit")
+//		h.Type("q")
+//		h.Wait()
+//	}
+//
+// Output is collected as the program writes it, and interpreted into a
+// vt.Screen the size of the terminal. WaitFor, WaitForString and WaitForScreen
+// block until it satisfies a condition, so tests do not depend on how the
+// renderer happens to split it into frames.
+package bubbwebtest
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb"
+	"github.com/tmc/bubbweb/vt"
+)
+
+// Default settings of a Harness.
+const (
+	DefaultWidth   = 80
+	DefaultHeight  = 24
+	DefaultTimeout = 5 * time.Second
+)
+
+// pollInterval is how often the harness drains the program's output.
+const pollInterval = 5 * time.Millisecond
+
+// Option configures a Harness.
+type Option func(*options)
+
+type options struct {
+	width, height int
+	timeout       time.Duration
+	bubbweb       []bubbweb.Option
+}
+
+// WithSize sets the terminal size the program is started with. A size of
+// zero skips the initial resize.
+func WithSize(width, height int) Option {
+	return func(o *options) {
+		o.width, o.height = width, height
+	}
+}
+
+// WithTimeout sets how long WaitFor, WaitForString and Wait wait before
+// failing the test.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithOptions passes options through to bubbweb.NewHeadless, for example
+// bubbweb.WithProgramOptions(tea.WithAltScreen()).
+func WithOptions(opts ...bubbweb.Option) Option {
+	return func(o *options) {
+		o.bubbweb = append(o.bubbweb, opts...)
+	}
+}
+
+// Harness runs a model headlessly and records what it writes.
+type Harness struct {
+	tb      testing.TB
+	prog    *bubbweb.Program
+	timeout time.Duration
+
+	mu     sync.Mutex
+	output bytes.Buffer // everything written so far
+	unread int          // offset of the output not yet returned by Read
+
+	done      chan struct{} // closed once Run returns
+	collected chan struct{} // closed once the final output is collected
+	model     tea.Model
+	err       error
+}
+
+// New starts model and returns a Harness driving it. The program is killed
+// when the test ends if it is still running.
+func New(tb testing.TB, model tea.Model, opts ...Option) *Harness {
+	tb.Helper()
+
+	o := options{width: DefaultWidth, height: DefaultHeight, timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	h := &Harness{
+		tb:        tb,
+		prog:      bubbweb.NewHeadless(model, o.bubbweb...),
+		timeout:   o.timeout,
+		done:      make(chan struct{}),
+		collected: make(chan struct{}),
+	}
+
+	go func() {
+		model, err := h.prog.Run()
+		h.mu.Lock()
+		h.model, h.err = model, err
+		h.mu.Unlock()
+		close(h.done)
+	}()
+	go h.collect()
+
+	tb.Cleanup(func() {
+		select {
+		case <-h.done:
+		default:
+			h.prog.Kill()
+			<-h.done
+		}
+	})
+
+	// Report a size the way the page does once the terminal is attached.
+	if o.width > 0 && o.height > 0 {
+		h.Resize(o.width, o.height)
+	}
+	return h
+}
+
+// collect moves the program's output into h.output until it exits.
+func (h *Harness) collect() {
+	defer close(h.collected)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		var exited bool
+		select {
+		case <-h.done:
+			exited = true
+		case <-ticker.C:
+		}
+		if data := h.prog.Output().Drain(); len(data) > 0 {
+			h.mu.Lock()
+			h.output.Write(data)
+			h.mu.Unlock()
+		}
+		if exited {
+			return
+		}
+	}
+}
+
+// Program returns the program under test.
+func (h *Harness) Program() *bubbweb.Program {
+	return h.prog
+}
+
+// Type sends s as keyboard input, like typing it into the page's terminal.
+// Escape sequences such as "\x1b[A" for the up arrow are decoded as keys.
+func (h *Harness) Type(s string) {
+	h.prog.Input([]byte(s))
+}
+
+// Paste pastes text, like bubbletea_paste. Whether it arrives as a paste
+// depends on the program having enabled bracketed paste by then, so wait for
+// its first frame before pasting.
+func (h *Harness) Paste(text string) {
+	if !h.prog.Paste(text) {
+		h.tb.Errorf("paste of %d bytes was not accepted", len(text))
+	}
+}
+
+// Send sends msg to the program.
+func (h *Harness) Send(msg tea.Msg) {
+	h.prog.Send(msg)
+}
+
+// Resize resizes the terminal, like bubbletea_resize.
+func (h *Harness) Resize(width, height int) {
+	h.prog.Resize(width, height)
+}
+
+// Mouse sends a mouse event, like bubbletea_mouse.
+func (h *Harness) Mouse(action tea.MouseAction, button tea.MouseButton, x, y int) {
+	h.prog.Mouse(tea.MouseMsg{Action: action, Button: button, X: x, Y: y})
+}
+
+// Click presses and releases the left mouse button at x, y.
+func (h *Harness) Click(x, y int) {
+	h.Mouse(tea.MouseActionPress, tea.MouseButtonLeft, x, y)
+	h.Mouse(tea.MouseActionRelease, tea.MouseButtonLeft, x, y)
+}
+
+// Output returns everything the program has written so far.
+func (h *Harness) Output() []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return bytes.Clone(h.output.Bytes())
+}
+
+// Read returns the output written since the previous call to Read.
+func (h *Harness) Read() []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data := bytes.Clone(h.output.Bytes()[h.unread:])
+	h.unread = h.output.Len()
+	return data
+}
+
+// Screen returns the screen the output so far has drawn. It is updated as
+// more output arrives; it must only be inspected, not written to.
+func (h *Harness) Screen() *vt.Screen {
+	return h.prog.Screen()
+}
+
+// WaitFor waits until cond reports true for the output written so far, and
+// fails the test if it does not within the timeout.
+func (h *Harness) WaitFor(cond func(output []byte) bool) {
+	h.tb.Helper()
+
+	deadline := time.Now().Add(h.timeout)
+	for {
+		if cond(h.Output()) {
+			return
+		}
+		if time.Now().After(deadline) {
+			h.tb.Fatalf("bubbwebtest: condition not met after %v; output:\n%q", h.timeout, h.Output())
+		}
+		select {
+		case <-h.done:
+			// Give the output written before exiting one last chance.
+			<-h.collected
+			if cond(h.Output()) {
+				return
+			}
+			h.tb.Fatalf("bubbwebtest: program exited before condition was met; output:\n%q", h.Output())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// WaitForString waits until the output contains s.
+func (h *Harness) WaitForString(s string) {
+	h.tb.Helper()
+	h.WaitFor(func(output []byte) bool {
+		return bytes.Contains(output, []byte(s))
+	})
+}
+
+// WaitForScreen waits until cond reports true for the screen.
+func (h *Harness) WaitForScreen(cond func(scr *vt.Screen) bool) {
+	h.tb.Helper()
+	h.WaitFor(func([]byte) bool {
+		return cond(h.Screen())
+	})
+}
+
+// Quit asks the program to quit, like bubbletea_quit.
+func (h *Harness) Quit() {
+	go h.prog.Quit()
+}
+
+// Kill stops the program immediately, like bubbletea_kill.
+func (h *Harness) Kill() {
+	go h.prog.Kill()
+}
+
+// Wait waits for the program to exit, failing the test if it does not within
+// the timeout, and returns its final model and error.
+func (h *Harness) Wait() (tea.Model, error) {
+	h.tb.Helper()
+
+	select {
+	case <-h.done:
+	case <-time.After(h.timeout):
+		h.tb.Fatalf("bubbwebtest: program still running after %v", h.timeout)
+	}
+	<-h.collected
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.model, h.err
+}
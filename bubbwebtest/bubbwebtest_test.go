@@ -0,0 +1,145 @@
+package bubbwebtest_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb/bubbwebtest"
+	"github.com/tmc/bubbweb/vt"
+)
+
+// model echoes what is typed and pasted into it, and quits on enter.
+type model struct {
+	width, height int
+	typed         string
+	pasted        []string
+	clicks        int
+}
+
+func (m model) Init() tea.Cmd { return nil }
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.MouseMsg:
+		if msg.Action == tea.MouseActionRelease {
+			m.clicks++
+		}
+	case tea.KeyMsg:
+		switch {
+		case msg.Paste:
+			m.pasted = append(m.pasted, string(msg.Runes))
+		case msg.Type == tea.KeyEnter:
+			return m, tea.Quit
+		case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
+			m.typed += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	return fmt.Sprintf("size %dx%d\ntyped: %s\npasted: %s\nclicks: %d",
+		m.width, m.height, m.typed, strings.Join(m.pasted, "|"), m.clicks)
+}
+
+func TestType(t *testing.T) {
+	h := bubbwebtest.New(t, model{})
+	h.WaitForString("typed:")
+	h.Type("hello world")
+	h.WaitForString("typed: hello world")
+	h.Type("\r")
+
+	m, err := h.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if got := m.(model).typed; got != "hello world" {
+		t.Errorf("final model typed %q, want %q", got, "hello world")
+	}
+}
+
+func TestResize(t *testing.T) {
+	h := bubbwebtest.New(t, model{}, bubbwebtest.WithSize(40, 10))
+	h.WaitForString("size 40x10")
+
+	h.Resize(60, 5)
+	h.WaitForString("size 60x5")
+	h.WaitForScreen(func(scr *vt.Screen) bool {
+		w, h := scr.Size()
+		return w == 60 && h == 5
+	})
+
+	// Sizes beyond what a page could show are clamped.
+	h.Resize(100000, 100000)
+	h.WaitForString("size 500x200")
+	h.WaitForScreen(func(scr *vt.Screen) bool {
+		w, h := scr.Size()
+		return w == 500 && h == 200
+	})
+}
+
+func TestPaste(t *testing.T) {
+	h := bubbwebtest.New(t, model{})
+	h.WaitForString("pasted:")
+	h.Paste("one\r\ntwo")
+	h.WaitForScreen(func(scr *vt.Screen) bool {
+		return strings.Contains(scr.String(), "two")
+	})
+	if got := h.Screen().Line(2); got != "pasted: one" {
+		t.Errorf("line 2 = %q, want the paste's first line", got)
+	}
+	if got := h.Screen().Line(3); got != "two" {
+		t.Errorf("line 3 = %q, want the paste's second line", got)
+	}
+}
+
+func TestClick(t *testing.T) {
+	h := bubbwebtest.New(t, model{})
+	h.WaitForString("clicks: 0")
+	h.Click(1, 1)
+	h.WaitForString("clicks: 1")
+}
+
+func TestRead(t *testing.T) {
+	h := bubbwebtest.New(t, model{})
+	h.WaitForString("typed:")
+	first := h.Read()
+	if !bytes.Contains(first, []byte("typed:")) {
+		t.Fatalf("Read() = %q, want the first frame", first)
+	}
+	h.Type("x")
+	h.WaitForString("typed: x")
+	if next := h.Read(); !bytes.Contains(next, []byte("x")) || bytes.Contains(next, first) {
+		t.Errorf("Read() = %q, want only the output since the first frame", next)
+	}
+	if !bytes.HasPrefix(h.Output(), first) {
+		t.Error("Output() does not start with the output already read")
+	}
+}
+
+func TestQuit(t *testing.T) {
+	h := bubbwebtest.New(t, model{})
+	h.WaitForString("typed:")
+	h.Quit()
+	m, err := h.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if _, ok := m.(model); !ok {
+		t.Errorf("final model is %T, want model", m)
+	}
+}
+
+func TestKill(t *testing.T) {
+	h := bubbwebtest.New(t, model{})
+	h.WaitForString("typed:")
+	h.Kill()
+	if _, err := h.Wait(); err == nil {
+		t.Error("Wait returned no error for a killed program")
+	}
+}
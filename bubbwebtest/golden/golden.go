@@ -0,0 +1,226 @@
+// Package golden compares what a program draws against golden files, to
+// catch visual regressions.
+//
+// A screen is recorded twice: as plain text in testdata/NAME.golden, and
+// annotated with colors and attributes in testdata/NAME.styled.golden, so a
+// change of color shows up even when the text is the same.
+//
+//	func TestEditor(t *testing.T) {
+//		for _, size := range [][2]int{{80, 24}, {120, 40}} {
+//			name := fmt.Sprintf("editor-%dx%d", size[0], size[1])
+//			t.Run(name, func(t *testing.T) {
+//				h := bubbwebtest.New(t, newModel(), bubbwebtest.WithSize(size[0], size[1]))
+//				h.WaitForString("Untitled")
+//				golden.AssertScreen(t, name, h.Screen())
+//			})
+//		}
+//	}
+//
+// Golden files are written from the current output instead of compared
+// against when Update is set, or when the test package defines an -update
+// flag and the tests run with it:
+//
+//	var _ = flag.Bool("update", false, "update golden files")
+//
+// The package does not define the flag itself, so it never clashes with one
+// the test package already has.
+//
+// Test binaries do not write to a terminal, so lipgloss renders without
+// colors by default. Call lipgloss.SetColorProfile(termenv.TrueColor), or
+// create styles from a renderer with that profile, for the styled files to
+// record them.
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmc/bubbweb/vt"
+)
+
+// Update makes Assert write golden files instead of comparing against them,
+// as if the tests ran with -update.
+var Update bool
+
+// updating reports whether golden files are to be written: if Update is set
+// or the test binary's -update flag is.
+func updating() bool {
+	if Update {
+		return true
+	}
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	on, _ := getter.Get().(bool)
+	return on
+}
+
+// Dir is the directory golden files are kept in, relative to the package
+// under test.
+var Dir = "testdata"
+
+// Assert compares got with the golden file testdata/NAME.golden, or writes
+// it there when updating.
+func Assert(tb testing.TB, name string, got []byte) {
+	tb.Helper()
+
+	path := filepath.Join(Dir, name+".golden")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("%v (run with -update to create it)", err)
+	}
+	// Tolerate files checked out with Windows line endings.
+	want = bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n"))
+	if !bytes.Equal(got, want) {
+		tb.Errorf("%s differs from the golden file (run with -update to accept):\n%s", path, diff(string(want), string(got)))
+	}
+}
+
+// AssertScreen compares scr with the golden files NAME.golden, holding its
+// text, and NAME.styled.golden, holding its text annotated with Styled. Both
+// are taken from the same snapshot of the screen.
+func AssertScreen(tb testing.TB, name string, scr *vt.Screen) {
+	tb.Helper()
+	snap := scr.Snapshot()
+	Assert(tb, name, []byte(text(snap)))
+	Assert(tb, name+".styled", []byte(styled(snap)))
+}
+
+// Text returns the text of scr, every row on its own line with trailing
+// blanks removed.
+func Text(scr *vt.Screen) string {
+	return text(scr.Snapshot())
+}
+
+func text(snap vt.Snapshot) string {
+	return strings.Join(snap.Lines(), "\n") + "\n"
+}
+
+// Styled returns the text of scr with its styles marked inline. A run of
+// cells in a style other than the default is preceded by the style in
+// braces and followed by {/}, as in
+//
+//	{fg=1 bold}Error{/}: file not found
+//
+// where colors are palette indexes or #rrggbb. Literal braces are doubled.
+// The cursor and the terminal state follow the rows.
+func Styled(scr *vt.Screen) string {
+	return styled(scr.Snapshot())
+}
+
+func styled(snap vt.Snapshot) string {
+	var b strings.Builder
+	for _, row := range snap.Cells {
+		var line strings.Builder
+		var style vt.Style
+		// Cells that only add blanks at the end of the line are dropped.
+		trimmed, trimmedStyle := 0, vt.Style{}
+		for _, c := range row {
+			if c.Width == 0 {
+				continue
+			}
+			if c.Style != style {
+				if style != (vt.Style{}) {
+					line.WriteString("{/}")
+				}
+				if c.Style != (vt.Style{}) {
+					line.WriteString("{" + styleString(c.Style) + "}")
+				}
+				style = c.Style
+			}
+			text := c.Text()
+			text = strings.ReplaceAll(text, "{", "{{")
+			text = strings.ReplaceAll(text, "}", "}}")
+			line.WriteString(text)
+			if strings.TrimSpace(c.Content) != "" || style != (vt.Style{}) {
+				trimmed, trimmedStyle = line.Len(), style
+			}
+		}
+		b.WriteString(line.String()[:trimmed])
+		if trimmedStyle != (vt.Style{}) {
+			b.WriteString("{/}")
+		}
+		b.WriteByte('\n')
+	}
+
+	cur := snap.Cursor
+	fmt.Fprintf(&b, "-- cursor %d,%d", cur.X, cur.Y)
+	if !cur.Visible {
+		b.WriteString(" hidden")
+	}
+	if snap.AltScreen {
+		b.WriteString(", alt screen")
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// styleString describes a style as space-separated settings.
+func styleString(st vt.Style) string {
+	var parts []string
+	if st.Fg.Type != vt.ColorDefault {
+		parts = append(parts, "fg="+colorString(st.Fg))
+	}
+	if st.Bg.Type != vt.ColorDefault {
+		parts = append(parts, "bg="+colorString(st.Bg))
+	}
+	if st.Attrs != 0 {
+		parts = append(parts, st.Attrs.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func colorString(c vt.Color) string {
+	if c.Type == vt.ColorRGB {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprint(c.Index)
+}
+
+// diff returns the lines that differ between want and got, prefixed with -
+// and + respectively, each with its line number.
+func diff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var w, g string
+		var hasW, hasG bool
+		if i < len(wantLines) {
+			w, hasW = wantLines[i], true
+		}
+		if i < len(gotLines) {
+			g, hasG = gotLines[i], true
+		}
+		if hasW && hasG && w == g {
+			continue
+		}
+		if hasW {
+			fmt.Fprintf(&b, "%4d - %s\n", i+1, w)
+		}
+		if hasG {
+			fmt.Fprintf(&b, "%4d + %s\n", i+1, g)
+		}
+	}
+	return b.String()
+}
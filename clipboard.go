@@ -0,0 +1,52 @@
+package bubbweb
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/tmc/bubbweb/clipboard"
+)
+
+// ClipboardMsg holds the text on the clipboard, sent in reply to an OSC 52
+// query, "\x1b]52;c;?\x07".
+type ClipboardMsg string
+
+// ClipboardErrorMsg reports that the browser refused to read or write the
+// clipboard on behalf of an OSC 52 sequence, usually because the user denied
+// permission or the page did not have focus.
+type ClipboardErrorMsg struct {
+	Err error
+}
+
+// handleClipboard routes the OSC 52 sequences the program writes to the
+// clipboard: it puts the text of a set request there, and replies to a query
+// with a ClipboardMsg.
+func (p *Program) handleClipboard() {
+	p.handleOSC(52, func(data string) {
+		// The selection is ignored, as a page only has the one clipboard.
+		_, payload, ok := strings.Cut(data, ";")
+		if !ok {
+			return
+		}
+		if payload == "?" {
+			go func() {
+				text, err := clipboard.ReadAll()
+				if err != nil {
+					p.Send(ClipboardErrorMsg{Err: err})
+					return
+				}
+				p.Send(ClipboardMsg(text))
+			}()
+			return
+		}
+		text, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return
+		}
+		go func() {
+			if err := clipboard.WriteAll(string(text)); err != nil {
+				p.Send(ClipboardErrorMsg{Err: err})
+			}
+		}()
+	})
+}
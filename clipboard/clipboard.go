@@ -0,0 +1,37 @@
+// Package clipboard reads and writes the system clipboard with the API of
+// github.com/atotto/clipboard, so it can replace that package in programs
+// that also build for the browser.
+//
+// Compiled to WebAssembly it uses the browser's Clipboard API, which may ask
+// the user for permission and only works while the page has focus; a refusal
+// is returned as an error. Elsewhere it uses github.com/atotto/clipboard.
+//
+// Calls block until the clipboard has been read or written, so in WebAssembly
+// they must not be made from a function called by JavaScript, which would
+// keep the browser from finishing the operation. A tea.Cmd is a good place:
+//
+//	func copyCmd(text string) tea.Cmd {
+//		return func() tea.Msg {
+//			return copiedMsg{err: clipboard.WriteAll(text)}
+//		}
+//	}
+package clipboard
+
+import "errors"
+
+// ErrUnsupported is returned when the platform has no clipboard to use.
+var ErrUnsupported = errors.New("clipboard: not supported")
+
+// ReadAll returns the text on the clipboard.
+func ReadAll() (string, error) {
+	return readAll()
+}
+
+// WriteAll puts text on the clipboard.
+func WriteAll(text string) error {
+	return writeAll(text)
+}
+
+// Unsupported is set during initialization if the platform has no clipboard,
+// to help callers decide whether or not to offer clipboard options.
+var Unsupported = unsupported()
@@ -0,0 +1,48 @@
+//go:build js
+// +build js
+
+package clipboard
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/tmc/bubbweb/internal/jsutil"
+)
+
+// api returns navigator.clipboard, or undefined if the browser lacks it, as
+// it does on pages not served over HTTPS.
+func api() js.Value {
+	navigator := js.Global().Get("navigator")
+	if navigator.IsUndefined() {
+		return js.Undefined()
+	}
+	return navigator.Get("clipboard")
+}
+
+func unsupported() bool {
+	return api().IsUndefined()
+}
+
+func readAll() (string, error) {
+	clipboard := api()
+	if clipboard.IsUndefined() {
+		return "", ErrUnsupported
+	}
+	text, err := jsutil.Await(clipboard.Call("readText"))
+	if err != nil {
+		return "", fmt.Errorf("clipboard: %w", err)
+	}
+	return text.String(), nil
+}
+
+func writeAll(text string) error {
+	clipboard := api()
+	if clipboard.IsUndefined() {
+		return ErrUnsupported
+	}
+	if _, err := jsutil.Await(clipboard.Call("writeText", text)); err != nil {
+		return fmt.Errorf("clipboard: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !js
+// +build !js
+
+package clipboard
+
+import "github.com/atotto/clipboard"
+
+func unsupported() bool {
+	return clipboard.Unsupported
+}
+
+func readAll() (string, error) {
+	return clipboard.ReadAll()
+}
+
+func writeAll(text string) error {
+	return clipboard.WriteAll(text)
+}
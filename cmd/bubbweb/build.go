@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmc/bubbweb/assets"
+)
+
+// wasmName is the file name the program is written to and the page loads.
+const wasmName = "bubbletea.wasm"
+
+// buildOptions describes a site to build.
+type buildOptions struct {
+	pkg     string      // package to build
+	out     string      // directory to write the site to
+	tags    string      // -tags passed to go build
+	ldflags string      // -ldflags passed to go build
+	cast    string      // recording to play instead of building pkg
+	page    assets.Page // page settings
+}
+
+func (o *buildOptions) flags(fs *flag.FlagSet) {
+	fs.StringVar(&o.out, "o", "dist", "write the site to `dir`")
+	fs.StringVar(&o.tags, "tags", "", "build `tags` passed to go build")
+	fs.StringVar(&o.ldflags, "ldflags", "", "`flags` passed to go build -ldflags")
+	fs.StringVar(&o.page.Title, "title", "", "page `title` (default the package directory name)")
+	fs.StringVar(&o.page.Theme, "theme", "auto", "page `theme`: dark, light or auto")
+	fs.StringVar(&o.cast, "cast", "", "play the asciicast recording in `file` instead of building a program")
+	fs.StringVar(&o.page.Instance, "instance", "", "`namespace` the program passes to bubbweb.WithNamespace")
+}
+
+// parse parses args into o, taking the package from the only positional
+// argument.
+func (o *buildOptions) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+		o.pkg = "."
+	case 1:
+		o.pkg = fs.Arg(0)
+	default:
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if o.page.Title == "" {
+		o.page.Title = packageTitle(o.pkg)
+		if o.cast != "" {
+			o.page.Title = strings.TrimSuffix(filepath.Base(o.cast), filepath.Ext(o.cast))
+		}
+	}
+	return nil
+}
+
+func runBuild(ctx context.Context, args []string) error {
+	var o buildOptions
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: bubbweb build [flags] [package]\n\n")
+		fs.PrintDefaults()
+	}
+	o.flags(fs)
+	if err := o.parse(fs, args); err != nil {
+		return err
+	}
+	if err := build(ctx, &o); err != nil {
+		return err
+	}
+	log.Printf("wrote %s", o.out)
+	return nil
+}
+
+// build compiles the program and writes the site around it.
+func build(ctx context.Context, o *buildOptions) error {
+	if err := os.MkdirAll(o.out, 0o755); err != nil {
+		return err
+	}
+	if o.cast != "" {
+		return buildPlayer(o)
+	}
+	if err := compile(ctx, o); err != nil {
+		return err
+	}
+
+	wasmExec, err := toolchainWasmExec(ctx)
+	if err != nil {
+		log.Printf("using bundled wasm_exec.js: %v", err)
+		wasmExec = assets.WasmExec()
+	}
+	return writeSite(o.out, o.page, siteFile{"wasm_exec.js", wasmExec})
+}
+
+// buildPlayer writes a site that plays the recording o.cast.
+func buildPlayer(o *buildOptions) error {
+	cast, err := os.ReadFile(o.cast)
+	if err != nil {
+		return err
+	}
+	page := o.page
+	page.CastURL = filepath.Base(o.cast)
+	return writeSite(o.out, page, siteFile{page.CastURL, cast})
+}
+
+// siteFile is a file to write to the site directory.
+type siteFile struct {
+	name string
+	data []byte
+}
+
+// writeSite writes the page and glue to dir, with the given extra files.
+func writeSite(dir string, page assets.Page, extra ...siteFile) error {
+	var index bytes.Buffer
+	if err := page.Render(&index); err != nil {
+		return err
+	}
+	files := append([]siteFile{
+		{"index.html", index.Bytes()},
+		{"bubbweb.js", assets.Glue()},
+	}, extra...)
+	for _, f := range files {
+		if err := writeFile(filepath.Join(dir, f.name), f.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compile builds the program to o.out/bubbletea.wasm. The output is built
+// next to its destination and renamed into place, so a server never sees a
+// partly written file.
+func compile(ctx context.Context, o *buildOptions) error {
+	tmp, err := os.CreateTemp(o.out, ".bubbletea-*.wasm")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	args := []string{"build", "-o", tmp.Name()}
+	if o.tags != "" {
+		args = append(args, "-tags", o.tags)
+	}
+	if o.ldflags != "" {
+		args = append(args, "-ldflags", o.ldflags)
+	}
+	args = append(args, o.pkg)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("building %s: %w", o.pkg, err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(o.out, wasmName))
+}
+
+// toolchainWasmExec returns the wasm_exec.js of the Go toolchain that
+// compiles the program, which must match it exactly.
+func toolchainWasmExec(ctx context.Context) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, "go", "env", "GOROOT").Output()
+	if err != nil {
+		return nil, fmt.Errorf("go env GOROOT: %w", err)
+	}
+	goroot := strings.TrimSpace(string(out))
+
+	// Go 1.24 moved the file from misc/wasm to lib/wasm.
+	for _, dir := range []string{"lib/wasm", "misc/wasm"} {
+		data, err := os.ReadFile(filepath.Join(goroot, dir, "wasm_exec.js"))
+		if err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("no wasm_exec.js in %s", goroot)
+}
+
+// writeFile writes data to name unless it already holds exactly data, so
+// unchanged files keep their modification time.
+func writeFile(name string, data []byte) error {
+	if old, err := os.ReadFile(name); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+// packageTitle derives a page title from a package path or directory.
+func packageTitle(pkg string) string {
+	if pkg == "." || strings.HasPrefix(pkg, "./") || strings.HasPrefix(pkg, "../") || filepath.IsAbs(pkg) {
+		if abs, err := filepath.Abs(pkg); err == nil {
+			return filepath.Base(abs)
+		}
+	}
+	return path.Base(strings.TrimSuffix(pkg, "/..."))
+}
@@ -0,0 +1,74 @@
+// Command bubbweb builds BubbleTea programs for the browser.
+//
+// Usage:
+//
+//	bubbweb build [flags] [package]
+//	bubbweb serve [flags] [package]
+//
+// The build subcommand compiles package, the current directory by default,
+// to WebAssembly and writes a directory ready to be served as a static site,
+// for example with GitHub Pages:
+//
+//	dist/
+//		index.html      the page, from the assets package
+//		bubbweb.js      the glue connecting xterm.js to the program
+//		wasm_exec.js    the runtime support file of the toolchain that built it
+//		bubbletea.wasm  the program
+//
+// With -cast, build writes a site that plays an asciicast recording, such as
+// one made with bubbweb.WithRecording, instead of building a program.
+//
+// The serve subcommand builds the same site and serves it for development.
+// It rebuilds the program whenever its sources change and tells open pages to
+// reload over a server-sent event stream.
+//
+// Run "bubbweb <command> -h" for the flags of each command.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: bubbweb <command> [flags] [package]\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  build    compile a program to WebAssembly and write a deployable site\n")
+	fmt.Fprintf(os.Stderr, "  serve    serve a program, rebuilding and reloading it as it changes\n")
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("bubbweb: ")
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "build":
+		err = runBuild(ctx, args)
+	case "serve":
+		err = runServe(ctx, args)
+	case "help", "-h", "-help", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "bubbweb: unknown command %q\n", cmd)
+		usage()
+	}
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// eventsPath is where the server streams reload notifications to the page.
+const eventsPath = "/_bubbweb/events"
+
+func init() {
+	// Browsers only compile WASM as it downloads when it is served with its
+	// registered type, which not every system's MIME table includes.
+	mime.AddExtensionType(".wasm", "application/wasm")
+}
+
+func runServe(ctx context.Context, args []string) error {
+	var (
+		o    buildOptions
+		addr string
+		poll time.Duration
+	)
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: bubbweb serve [flags] [package]\n\n")
+		fs.PrintDefaults()
+	}
+	o.flags(fs)
+	fs.StringVar(&addr, "addr", "localhost:8080", "listen on `address`")
+	fs.DurationVar(&poll, "poll", 500*time.Millisecond, "check the sources for changes every `interval`")
+	if err := o.parse(fs, args); err != nil {
+		return err
+	}
+
+	// Unless asked to keep it, build the site somewhere out of the way.
+	if !flagSet(fs, "o") {
+		dir, err := os.MkdirTemp("", "bubbweb-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		o.out = dir
+	}
+	o.page.LiveReload = eventsPath
+
+	events := newBroadcaster()
+	rebuild := func() {
+		start := time.Now()
+		if err := build(ctx, &o); err != nil {
+			log.Print(err)
+			events.send("builderror", err.Error())
+			return
+		}
+		log.Printf("built %s in %v", o.pkg, time.Since(start).Round(time.Millisecond))
+		events.send("reload", "")
+	}
+	rebuild()
+
+	mux := http.NewServeMux()
+	mux.Handle(eventsPath, events)
+	mux.Handle("/", &siteHandler{dir: o.out})
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("serving %s on http://%s", o.pkg, ln.Addr())
+
+	go watch(ctx, &o, poll, rebuild)
+	go func() {
+		<-ctx.Done()
+		events.close()
+		shutdown, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// flagSet reports whether the flag name was given on the command line.
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// watch calls rebuild whenever a source of the program changes, checking
+// every interval until ctx is done.
+func watch(ctx context.Context, o *buildOptions, interval time.Duration, rebuild func()) {
+	last := snapshot(ctx, o)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if cur := snapshot(ctx, o); cur != last {
+			last = cur
+			rebuild()
+		}
+	}
+}
+
+// snapshot fingerprints the files the program is built from: the Go sources,
+// embedded and other files go list reports for every package it imports from
+// its own module, and that module's go.mod and go.sum. Dependencies outside
+// the main module only change through go.mod. Files other than sources are
+// left out if they are in the output directory, since each build rewrites
+// them when the site is built into the package's own directory with -o .
+func snapshot(ctx context.Context, o *buildOptions) string {
+	args := []string{"list", "-deps",
+		"-json=Dir,Module,GoFiles,CgoFiles,SFiles,OtherFiles,EmbedFiles,IgnoredGoFiles,IgnoredOtherFiles"}
+	if o.tags != "" {
+		args = append(args, "-tags", o.tags)
+	}
+	args = append(args, o.pkg)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	out, err := cmd.Output()
+	if err != nil {
+		// Fingerprint the error so fixing it triggers a rebuild.
+		return err.Error()
+	}
+
+	outDir, err := filepath.Abs(o.out)
+	if err != nil {
+		outDir = o.out
+	}
+	h := sha256.New()
+	built := func(name string) bool {
+		rel, err := filepath.Rel(outDir, name)
+		return err == nil && filepath.IsLocal(rel)
+	}
+	stat := func(name string) {
+		fmt.Fprintf(h, "%s", name)
+		if info, err := os.Stat(name); err == nil {
+			fmt.Fprintf(h, " %d %d", info.Size(), info.ModTime().UnixNano())
+		}
+		fmt.Fprintln(h)
+	}
+	seen := make(map[string]bool)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var pkg struct {
+			Dir    string
+			Module *struct {
+				Main  bool
+				GoMod string
+			}
+			GoFiles, CgoFiles, SFiles, OtherFiles, EmbedFiles []string
+			IgnoredGoFiles, IgnoredOtherFiles                 []string
+		}
+		if err := dec.Decode(&pkg); err != nil {
+			if err != io.EOF {
+				return err.Error()
+			}
+			break
+		}
+		if pkg.Module == nil || !pkg.Module.Main {
+			continue
+		}
+		if gomod := pkg.Module.GoMod; gomod != "" && !seen[gomod] {
+			seen[gomod] = true
+			stat(gomod)
+			stat(strings.TrimSuffix(gomod, ".mod") + ".sum")
+		}
+		for _, files := range [][]string{pkg.GoFiles, pkg.CgoFiles, pkg.SFiles, pkg.IgnoredGoFiles} {
+			for _, name := range files {
+				stat(filepath.Join(pkg.Dir, name))
+			}
+		}
+		for _, files := range [][]string{pkg.OtherFiles, pkg.EmbedFiles, pkg.IgnoredOtherFiles} {
+			for _, name := range files {
+				if name := filepath.Join(pkg.Dir, name); !built(name) {
+					stat(name)
+				}
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// siteHandler serves the built site with strong ETags, so the page's
+// conditional requests for bubbletea.wasm only transfer it when it changed.
+type siteHandler struct {
+	dir string
+
+	mu    sync.Mutex
+	etags map[string]etag
+}
+
+// etag caches a file's entity tag for as long as its size and time match.
+type etag struct {
+	size    int64
+	modTime time.Time
+	tag     string
+}
+
+func (h *siteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := filepath.Join(h.dir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = filepath.Join(name, "index.html")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	tag, err := h.etag(name, info)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// etag returns the entity tag of the file name, hashing it if it changed.
+func (h *siteHandler) etag(name string, info fs.FileInfo) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if e, ok := h.etags[name]; ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e.tag, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if h.etags == nil {
+		h.etags = make(map[string]etag)
+	}
+	h.etags[name] = etag{size: info.Size(), modTime: info.ModTime(), tag: tag}
+	return tag, nil
+}
+
+// broadcaster streams events to every connected page as server-sent events.
+type broadcaster struct {
+	mu      sync.Mutex
+	clients map[chan event]struct{}
+	done    chan struct{}
+}
+
+type event struct {
+	name string
+	data string
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{clients: make(map[chan event]struct{}), done: make(chan struct{})}
+}
+
+// send delivers an event to the connected pages, skipping any that are still
+// busy with the previous one.
+func (b *broadcaster) send(name, data string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.clients {
+		select {
+		case c <- event{name, data}:
+		default:
+		}
+	}
+}
+
+// close disconnects every page.
+func (b *broadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	select {
+	case <-b.done:
+	default:
+		close(b.done)
+	}
+}
+
+func (b *broadcaster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	c := make(chan event, 1)
+	b.mu.Lock()
+	b.clients[c] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, c)
+		b.mu.Unlock()
+	}()
+
+	keepalive := time.NewTicker(30 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-b.done:
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case e := <-c:
+			data, _ := json.Marshal(e.data)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -23,18 +23,52 @@
 //	}
 //
 // When compiled to WebAssembly, the program provides JavaScript bindings
-// that connect to an xterm.js terminal in the browser. The included example
-// demonstrates the complete setup, including HTML and JavaScript.
+// that connect to an xterm.js terminal in the browser. The assets package
+// holds the page and JavaScript glue, and cmd/bubbweb builds a site from them;
+// the included example is built that way.
 //
 // The bubbweb package handles input and output between the BubbleTea application
-// and the browser. It exposes four JavaScript functions:
+// and the browser. It exposes these JavaScript functions:
 //
-//   - bubbletea_write: Sends input from JavaScript to the Go program
-//   - bubbletea_read: Reads output from the Go program
+//   - bubbletea_write: Sends input, a Uint8Array or string, from JavaScript to the Go program
+//   - bubbletea_read: Reads output from the Go program as a Uint8Array
+//   - bubbletea_onoutput: Registers a callback that receives output as a
+//     Uint8Array as soon as it is written, at most once per animation frame
+//   - bubbletea_paste: Pastes a string into the Go program; see Program.Paste
+//   - bubbletea_focus: Reports that the terminal gained or lost focus; see
+//     Program.Focus
+//   - bubbletea_link: Handles a click on an OSC 8 hyperlink, returning whether
+//     the page should open it; see Program.Link
 //   - bubbletea_resize: Sends terminal resize events to the Go program
 //   - bubbletea_mouse: Sends mouse events to the Go program
+//   - bubbletea_key: Sends a KeyboardEvent to the Go program as a tea.KeyMsg,
+//     and returns whether it maps to one; see KeyEvent.KeyMsg
+//   - bubbletea_quit: Asks the Go program to quit, like tea.Quit
+//   - bubbletea_kill: Stops the Go program immediately, like tea.Program.Kill
+//   - bubbletea_onexit: Registers a callback that is called with an exit
+//     object when the Go program exits
+//   - bubbletea_ontitle: Registers a callback that receives each window title
+//     the Go program sets, starting with the current one
+//   - bubbletea_recording: Returns the session recorded so far as an
+//     asciicast v2 Blob, if the program was created with WithRecording
+//   - bubbletea_screenshot: Returns the current screen as a standalone SVG
+//     or HTML string, in a dark, light or xterm.js theme
 //
-// Mouse support is enabled by default and works with standard BubbleTea mouse handling.
+// It also sets bubbletea_exited to a Promise that settles when the program
+// exits, if it was created with New rather than NewProgram. It resolves with an exit object after a normal quit, and rejects with
+// an Error carrying the same reason and model fields when the program was
+// killed, failed or panicked. The exit object has the form
+//
+//	{reason: "quit" | "killed" | "error" | "panic", error: string | null, model: any}
+//
+// where model is the JSON encoding of the final model's ExitSummary, present
+// only if the model implements ExitSummarizer.
+//
+// Output never splits a multi-byte UTF-8 sequence: an incomplete rune at the
+// end of the buffer is held back until the rest of it has been written.
+//
+// Mouse support is enabled by default in WASM (see WithMouse) and works with
+// standard BubbleTea mouse handling.
 // Your application will receive mouse events through the tea.MouseMsg type:
 //
 //	case tea.MouseMsg:
@@ -50,6 +84,29 @@
 //	    }
 //
 // These JavaScript functions are called by the JavaScript code in the HTML page.
+// Once a program created with New exits they are removed and released, so
+// the page can no longer call into it.
+//
+// bubbweb itself is configured with Options, which New accepts alongside
+// BubbleTea's own program options wrapped in WithProgramOptions. Every Option
+// exists on all platforms, so the same code builds natively and for WASM;
+// browser-only settings are ignored natively.
+//
+// To host several programs on one page, give each its own namespace. Its
+// functions are then registered on globalThis.bubbweb.instances[name]
+// rather than on the global object:
+//
+//	prog := bubbweb.New(model,
+//		bubbweb.WithNamespace("editor"),
+//		bubbweb.WithProgramOptions(tea.WithAltScreen()))
+//
+// Outside WASM, Handler serves a program to the same page over a WebSocket
+// instead, running one model per connection on the server. The page sends the
+// requests it would otherwise make through the functions above.
+//
+// NewHeadless wires a program up the same way on any platform but leaves it
+// to be driven from Go through Input, Resize and Mouse, with its output read
+// from Output. The bubbwebtest package builds a test harness on it.
 //
 // To build a WebAssembly application using bubbweb:
 //
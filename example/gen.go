@@ -1,18 +1,13 @@
-//go:generate go mod tidy
-//go:generate go mod edit -dropreplace=github.com/charmbracelet/bubbletea
-//go:generate go mod edit -dropreplace=github.com/atotto/clipboard
+// Build the example's site into this directory, ready to be served by any
+// static file server:
+//
+//	go generate
+//	go run github.com/tmc/serve@latest
+//	open http://localhost:8080
+//
+// During development, go run ../cmd/bubbweb serve . does the same and
+// rebuilds on every change.
+
+//go:generate go run ../cmd/bubbweb build -o . -title "bubbweb - bubbletea in the browser" .
 
-//go:generate go mod tidy
-//go:generate go mod edit -replace=github.com/charmbracelet/bubbletea=github.com/tmc/bubbletea@wasm
-
-//go:generate go mod tidy
-//go:generate go mod edit -replace=github.com/atotto/clipboard=github.com/tmc/clipboard@wasm
-//go:generate go mod tidy
-
-//go:generate cp "$GOROOT/lib/wasm/wasm_exec.js" ./wasm_exec.js
-//go:generate env GOOS=js GOARCH=wasm go build -o bubbletea.wasm .
-
-//go:generate echo To launch:
-//go:generate echo go run github.com/tmc/serve@latest
-//go:generate echo open http://localhost:8080
 package main
@@ -190,6 +190,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.height = msg.Height
 		m.width = msg.Width
+		// Drop the help entries that do not fit rather than letting the
+		// terminal cut the line off.
+		m.help.Width = msg.Width
 	case tea.MouseMsg:
 		m.mousePosition = fmt.Sprintf("(%d,%d)", msg.X, msg.Y)
 		m.mouseEvent = fmt.Sprint(msg)
@@ -564,10 +567,13 @@ func (m model) View() string {
 
 func main() {
 	// Enable both mouse cell motion and all motion for better mouse interactions
-	prog := bubbweb.NewProgram(newModel(),
+	prog := bubbweb.New(newModel(), bubbweb.WithProgramOptions(
 		tea.WithMouseAllMotion(),  // Track all mouse motion
-		tea.WithMouseCellMotion()) // Track cell-based mouse motion
+		tea.WithMouseCellMotion(), // Track cell-based mouse motion
+	))
 
+	// In WASM the page also learns how the program exited, through
+	// bubbletea_exited.
 	if _, err := prog.Run(); err != nil {
 		fmt.Println("Error while running program:", err)
 		os.Exit(1)
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/tmc/bubbweb/bubbwebtest"
+	"github.com/tmc/bubbweb/bubbwebtest/golden"
+	"github.com/tmc/bubbweb/vt"
+)
+
+var _ = flag.Bool("update", false, "update golden files")
+
+func init() {
+	// Render colors as in a browser with a dark theme, whatever runs the tests.
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	lipgloss.SetHasDarkBackground(true)
+}
+
+// staticModel returns the editor with cursors that do not blink, so the
+// screen does not change while it is compared.
+func staticModel() model {
+	m := newModel()
+	for i := range m.inputs {
+		m.inputs[i].Cursor.SetMode(cursor.CursorStatic)
+	}
+	return m
+}
+
+func TestEditorGolden(t *testing.T) {
+	for _, size := range [][2]int{{80, 24}, {120, 40}, {50, 16}} {
+		name := fmt.Sprintf("editor-%dx%d", size[0], size[1])
+		t.Run(name, func(t *testing.T) {
+			h := bubbwebtest.New(t, staticModel(), bubbwebtest.WithSize(size[0], size[1]))
+			h.WaitForScreen(func(scr *vt.Screen) bool {
+				return scr.AltScreen() && strings.Contains(scr.String(), "Mouse:")
+			})
+			golden.AssertScreen(t, name, h.Screen())
+		})
+	}
+}
+
+func TestEditorAddPane(t *testing.T) {
+	h := bubbwebtest.New(t, staticModel())
+	h.WaitForString("Mouse:")
+	h.Type("hello")
+	h.Type("\x0e") // ctrl+n
+	h.WaitForScreen(func(scr *vt.Screen) bool {
+		return strings.Contains(scr.String(), "hello") && strings.Count(scr.Line(2), "Type something!") == 2
+	})
+	golden.AssertScreen(t, "editor-add-pane", h.Screen())
+}
@@ -0,0 +1,42 @@
+package bubbweb
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ExitReason describes how a program exited.
+type ExitReason string
+
+const (
+	// ExitQuit means the program quit normally, for example via tea.Quit.
+	ExitQuit ExitReason = "quit"
+	// ExitKilled means the program was killed or its context was canceled.
+	ExitKilled ExitReason = "killed"
+	// ExitError means the program stopped because of an error.
+	ExitError ExitReason = "error"
+	// ExitPanic means the program panicked.
+	ExitPanic ExitReason = "panic"
+)
+
+// ExitSummarizer is implemented by models that report a summary of their
+// final state when the program exits. The summary must be JSON-serializable;
+// in WASM it is passed to the page along with the ExitReason.
+type ExitSummarizer interface {
+	ExitSummary() any
+}
+
+// exitReason classifies the values returned by tea.Program.Run.
+func exitReason(model tea.Model, err error) ExitReason {
+	switch {
+	case errors.Is(err, tea.ErrProgramKilled):
+		return ExitKilled
+	case err != nil:
+		return ExitError
+	case model == nil:
+		// tea recovers from panics and returns neither a model nor an error.
+		return ExitPanic
+	}
+	return ExitQuit
+}
@@ -0,0 +1,28 @@
+package bubbweb
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb/vt"
+)
+
+// Focus tells the program the terminal has gained or lost focus, like
+// bubbletea_focus, and reports whether it was told. As in a terminal, the
+// program receives a tea.FocusMsg or tea.BlurMsg only while it has focus
+// reporting enabled, with tea.WithReportFocus.
+func (p *Program) Focus(focused bool) bool {
+	if p.screen == nil || !p.screen.Mode(vt.ModeFocusReporting) {
+		return false
+	}
+	if focused {
+		if p.recording != nil {
+			p.recording.Input([]byte("\x1b[I"))
+		}
+		p.Send(tea.FocusMsg{})
+	} else {
+		if p.recording != nil {
+			p.recording.Input([]byte("\x1b[O"))
+		}
+		p.Send(tea.BlurMsg{})
+	}
+	return true
+}
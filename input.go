@@ -0,0 +1,70 @@
+package bubbweb
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// MinReadBuffer is a custom buffer for handling bubbletea's input expectations in WASM.
+//
+// Reads block until data is written or the buffer is closed. It is safe for
+// concurrent use, and the zero value is ready to use.
+type MinReadBuffer struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	closed bool
+}
+
+// wait blocks until data is available or the buffer is closed.
+// The caller must hold b.mu.
+func (b *MinReadBuffer) wait() {
+	if b.cond == nil {
+		b.cond = sync.NewCond(&b.mu)
+	}
+	for b.buf.Len() == 0 && !b.closed {
+		b.cond.Wait()
+	}
+}
+
+// Read from the buffer, blocking until data is available.
+// Once the buffer is closed and drained, Read returns io.EOF.
+func (b *MinReadBuffer) Read(p []byte) (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.wait()
+	if b.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return b.buf.Read(p)
+}
+
+// Write to the buffer, waking any blocked reader.
+func (b *MinReadBuffer) Write(p []byte) (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n, err = b.buf.Write(p)
+	if b.cond != nil {
+		b.cond.Broadcast()
+	}
+	return n, err
+}
+
+// Close closes the buffer, unblocking any pending Read.
+// Data already written can still be read.
+func (b *MinReadBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	if b.cond != nil {
+		b.cond.Broadcast()
+	}
+	return nil
+}
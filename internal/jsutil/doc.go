@@ -0,0 +1,3 @@
+// Package jsutil holds helpers for calling JavaScript APIs from WASM that
+// several bubbweb packages share.
+package jsutil
@@ -0,0 +1,43 @@
+//go:build js
+// +build js
+
+package jsutil
+
+import (
+	"errors"
+	"syscall/js"
+)
+
+// Await waits for promise to settle, returning its value or the reason it
+// was rejected as an error. It must not be called from a js.Func callback,
+// which would keep the promise from settling.
+func Await(promise js.Value) (js.Value, error) {
+	type result struct {
+		value js.Value
+		err   error
+	}
+	done := make(chan result, 1)
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- result{value: args[0]}
+		return nil
+	})
+	defer onResolve.Release()
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- result{err: Error(args[0])}
+		return nil
+	})
+	defer onReject.Release()
+
+	promise.Call("then", onResolve, onReject)
+	r := <-done
+	return r.value, r.err
+}
+
+// Error converts a thrown JavaScript value or the reason a promise was
+// rejected to an error, such as "NotAllowedError: Document is not focused."
+func Error(reason js.Value) error {
+	if reason.Type() == js.TypeObject && reason.Get("message").Type() == js.TypeString {
+		return errors.New(reason.Get("name").String() + ": " + reason.Get("message").String())
+	}
+	return errors.New(reason.String())
+}
@@ -0,0 +1,19 @@
+// Package utf8util holds UTF-8 helpers shared by bubbweb's output buffer and
+// its recordings, which must both avoid splitting a rune between chunks.
+package utf8util
+
+import "unicode/utf8"
+
+// Incomplete returns the length of the truncated UTF-8 sequence at the end
+// of p, or zero if p ends on a rune boundary.
+func Incomplete(p []byte) int {
+	for n := 1; n < utf8.UTFMax && n <= len(p); n++ {
+		if tail := p[len(p)-n:]; utf8.RuneStart(tail[0]) {
+			if utf8.FullRune(tail) {
+				return 0
+			}
+			return n
+		}
+	}
+	return 0
+}
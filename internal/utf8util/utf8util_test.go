@@ -0,0 +1,24 @@
+package utf8util
+
+import "testing"
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 0},
+		{"a界", 0},
+		{"a\xe7", 1},
+		{"a\xe7\x95", 2},
+		{"\xf0\x9f\x98", 3},
+		{"\xf0\x9f\x98\x80", 0},
+		{"a\x95", 0}, // a stray continuation byte is not held back
+	}
+	for _, tt := range tests {
+		if got := Incomplete([]byte(tt.in)); got != tt.want {
+			t.Errorf("Incomplete(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package bubbweb
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// KeyEvent is a key press as the browser reports it in a KeyboardEvent, whose
+// fields of the same names it mirrors.
+type KeyEvent struct {
+	// Key is the key's value, such as "a", "A", "Enter" or "ArrowUp".
+	Key string
+	// Code is the physical key, such as "KeyA", regardless of layout.
+	Code string
+
+	Ctrl  bool
+	Alt   bool
+	Shift bool
+	Meta  bool
+
+	// Repeat is set when the key is held down. Terminals report repeats as
+	// ordinary presses, and so does KeyMsg.
+	Repeat bool
+}
+
+// namedKeys maps the Key of non-printing keys to the key types a terminal
+// reports for them, indexed by modifiers: none, shift, ctrl and ctrl+shift.
+// Terminals have no distinct key for most combinations, which then fall back
+// to the nearest one. Alt is reported separately, as tea.KeyMsg.Alt.
+var namedKeys = map[string][4]tea.KeyType{
+	"Enter":     same(tea.KeyEnter),
+	"Tab":       {tea.KeyTab, tea.KeyShiftTab, tea.KeyTab, tea.KeyShiftTab},
+	"Backspace": same(tea.KeyBackspace),
+	"Escape":    same(tea.KeyEsc),
+	"Delete":    same(tea.KeyDelete),
+	"Insert":    same(tea.KeyInsert),
+
+	"ArrowUp":    {tea.KeyUp, tea.KeyShiftUp, tea.KeyCtrlUp, tea.KeyCtrlShiftUp},
+	"ArrowDown":  {tea.KeyDown, tea.KeyShiftDown, tea.KeyCtrlDown, tea.KeyCtrlShiftDown},
+	"ArrowRight": {tea.KeyRight, tea.KeyShiftRight, tea.KeyCtrlRight, tea.KeyCtrlShiftRight},
+	"ArrowLeft":  {tea.KeyLeft, tea.KeyShiftLeft, tea.KeyCtrlLeft, tea.KeyCtrlShiftLeft},
+	"Home":       {tea.KeyHome, tea.KeyShiftHome, tea.KeyCtrlHome, tea.KeyCtrlShiftHome},
+	"End":        {tea.KeyEnd, tea.KeyShiftEnd, tea.KeyCtrlEnd, tea.KeyCtrlShiftEnd},
+	"PageUp":     {tea.KeyPgUp, tea.KeyPgUp, tea.KeyCtrlPgUp, tea.KeyCtrlPgUp},
+	"PageDown":   {tea.KeyPgDown, tea.KeyPgDown, tea.KeyCtrlPgDown, tea.KeyCtrlPgDown},
+
+	"F1":  same(tea.KeyF1),
+	"F2":  same(tea.KeyF2),
+	"F3":  same(tea.KeyF3),
+	"F4":  same(tea.KeyF4),
+	"F5":  same(tea.KeyF5),
+	"F6":  same(tea.KeyF6),
+	"F7":  same(tea.KeyF7),
+	"F8":  same(tea.KeyF8),
+	"F9":  same(tea.KeyF9),
+	"F10": same(tea.KeyF10),
+	"F11": same(tea.KeyF11),
+	"F12": same(tea.KeyF12),
+	"F13": same(tea.KeyF13),
+	"F14": same(tea.KeyF14),
+	"F15": same(tea.KeyF15),
+	"F16": same(tea.KeyF16),
+	"F17": same(tea.KeyF17),
+	"F18": same(tea.KeyF18),
+	"F19": same(tea.KeyF19),
+	"F20": same(tea.KeyF20),
+}
+
+// same returns the namedKeys entry of a key that modifiers do not change.
+func same(t tea.KeyType) [4]tea.KeyType {
+	return [4]tea.KeyType{t, t, t, t}
+}
+
+// ctrlSymbols maps the characters that form control keys with ctrl, other
+// than letters, to those keys.
+var ctrlSymbols = map[string]tea.KeyType{
+	" ":  tea.KeyCtrlAt,
+	"@":  tea.KeyCtrlAt,
+	"[":  tea.KeyCtrlOpenBracket,
+	"\\": tea.KeyCtrlBackslash,
+	"]":  tea.KeyCtrlCloseBracket,
+	"^":  tea.KeyCtrlCaret,
+	"_":  tea.KeyCtrlUnderscore,
+	"?":  tea.KeyCtrlQuestionMark,
+}
+
+// KeyMsg returns the tea.KeyMsg a terminal would report for e. It reports
+// false for keys a terminal cannot send, which the page should leave to the
+// browser: modifiers on their own, dead keys, anything held with meta, and
+// ctrl with a key that forms no control character.
+//
+// The mapping is:
+//
+//   - Named keys such as Enter, ArrowUp, PageDown and F1 to F20 map to their
+//     key types, using the shift, ctrl and ctrl+shift variants bubbletea has,
+//     such as tea.KeyShiftTab and tea.KeyCtrlShiftUp.
+//   - Ctrl with a letter maps to tea.KeyCtrlA to tea.KeyCtrlZ. The letter is
+//     taken from Code when the layout does not produce a Latin one, so
+//     ctrl+c is ctrl+c on any keyboard.
+//   - Ctrl with space, @, [, \, ], ^, _ or ? maps to the control key of the
+//     same name, as in a terminal.
+//   - Space maps to tea.KeySpace, and any other single character to
+//     tea.KeyRunes.
+//   - Alt with any of these sets tea.KeyMsg.Alt, instead of prefixing the key
+//     with an escape that bubbletea would have to tell apart from Esc.
+func (e KeyEvent) KeyMsg() (tea.KeyMsg, bool) {
+	if e.Meta {
+		return tea.KeyMsg{}, false
+	}
+	msg := tea.KeyMsg{Alt: e.Alt}
+
+	if types, ok := namedKeys[e.Key]; ok {
+		i := 0
+		if e.Shift {
+			i |= 1
+		}
+		if e.Ctrl {
+			i |= 2
+		}
+		msg.Type = types[i]
+		return msg, true
+	}
+
+	if utf8.RuneCountInString(e.Key) != 1 {
+		// Modifiers, dead keys and keys with no terminal equivalent.
+		return tea.KeyMsg{}, false
+	}
+
+	if e.Ctrl {
+		letter := strings.ToLower(e.Key)
+		if (letter < "a" || letter > "z") && strings.HasPrefix(e.Code, "Key") {
+			letter = strings.ToLower(strings.TrimPrefix(e.Code, "Key"))
+		}
+		if len(letter) == 1 && letter >= "a" && letter <= "z" {
+			msg.Type = tea.KeyCtrlA + tea.KeyType(letter[0]-'a')
+			return msg, true
+		}
+		if t, ok := ctrlSymbols[e.Key]; ok {
+			msg.Type = t
+			return msg, true
+		}
+		return tea.KeyMsg{}, false
+	}
+
+	if e.Key == " " {
+		msg.Type = tea.KeySpace
+		msg.Runes = []rune{' '}
+		return msg, true
+	}
+	msg.Type = tea.KeyRunes
+	msg.Runes = []rune(e.Key)
+	return msg, true
+}
+
+// keySequences maps the key types of KeyEvent.KeyMsg that are not control
+// characters or runes to the sequences xterm sends for them.
+var keySequences = map[tea.KeyType]string{
+	tea.KeyShiftTab: "\x1b[Z",
+	tea.KeyDelete:   "\x1b[3~",
+	tea.KeyInsert:   "\x1b[2~",
+
+	tea.KeyUp:             "\x1b[A",
+	tea.KeyDown:           "\x1b[B",
+	tea.KeyRight:          "\x1b[C",
+	tea.KeyLeft:           "\x1b[D",
+	tea.KeyHome:           "\x1b[H",
+	tea.KeyEnd:            "\x1b[F",
+	tea.KeyShiftUp:        "\x1b[1;2A",
+	tea.KeyShiftDown:      "\x1b[1;2B",
+	tea.KeyShiftRight:     "\x1b[1;2C",
+	tea.KeyShiftLeft:      "\x1b[1;2D",
+	tea.KeyShiftHome:      "\x1b[1;2H",
+	tea.KeyShiftEnd:       "\x1b[1;2F",
+	tea.KeyCtrlUp:         "\x1b[1;5A",
+	tea.KeyCtrlDown:       "\x1b[1;5B",
+	tea.KeyCtrlRight:      "\x1b[1;5C",
+	tea.KeyCtrlLeft:       "\x1b[1;5D",
+	tea.KeyCtrlHome:       "\x1b[1;5H",
+	tea.KeyCtrlEnd:        "\x1b[1;5F",
+	tea.KeyCtrlShiftUp:    "\x1b[1;6A",
+	tea.KeyCtrlShiftDown:  "\x1b[1;6B",
+	tea.KeyCtrlShiftRight: "\x1b[1;6C",
+	tea.KeyCtrlShiftLeft:  "\x1b[1;6D",
+	tea.KeyCtrlShiftHome:  "\x1b[1;6H",
+	tea.KeyCtrlShiftEnd:   "\x1b[1;6F",
+	tea.KeyPgUp:           "\x1b[5~",
+	tea.KeyPgDown:         "\x1b[6~",
+	tea.KeyCtrlPgUp:       "\x1b[5;5~",
+	tea.KeyCtrlPgDown:     "\x1b[6;5~",
+
+	tea.KeyF1:  "\x1bOP",
+	tea.KeyF2:  "\x1bOQ",
+	tea.KeyF3:  "\x1bOR",
+	tea.KeyF4:  "\x1bOS",
+	tea.KeyF5:  "\x1b[15~",
+	tea.KeyF6:  "\x1b[17~",
+	tea.KeyF7:  "\x1b[18~",
+	tea.KeyF8:  "\x1b[19~",
+	tea.KeyF9:  "\x1b[20~",
+	tea.KeyF10: "\x1b[21~",
+	tea.KeyF11: "\x1b[23~",
+	tea.KeyF12: "\x1b[24~",
+	tea.KeyF13: "\x1b[25~",
+	tea.KeyF14: "\x1b[26~",
+	tea.KeyF15: "\x1b[28~",
+	tea.KeyF16: "\x1b[29~",
+	tea.KeyF17: "\x1b[31~",
+	tea.KeyF18: "\x1b[32~",
+	tea.KeyF19: "\x1b[33~",
+	tea.KeyF20: "\x1b[34~",
+}
+
+// keySequence returns what a terminal sends for msg, one of the keys
+// KeyEvent.KeyMsg reports, with alt as an escape prefix.
+func keySequence(msg tea.KeyMsg) []byte {
+	var seq string
+	switch {
+	case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
+		seq = string(msg.Runes)
+	case msg.Type >= 0:
+		// Control characters have the key type of their own value.
+		seq = string(rune(msg.Type))
+	default:
+		seq = keySequences[msg.Type]
+	}
+	if msg.Alt {
+		seq = "\x1b" + seq
+	}
+	return []byte(seq)
+}
+
+// Key sends a key press to the program, like bubbletea_key, and reports
+// whether it maps to a key the program can receive. See KeyEvent.KeyMsg.
+//
+// A recording records the key as the sequence a terminal sends for it.
+func (p *Program) Key(e KeyEvent) bool {
+	msg, ok := e.KeyMsg()
+	if ok {
+		if p.recording != nil {
+			p.recording.Input(keySequence(msg))
+		}
+		p.Send(msg)
+	}
+	return ok
+}
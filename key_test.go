@@ -0,0 +1,64 @@
+package bubbweb_test
+
+import (
+	"testing"
+
+	"github.com/tmc/bubbweb"
+)
+
+func TestKeyEventKeyMsg(t *testing.T) {
+	tests := []struct {
+		event bubbweb.KeyEvent
+		want  string // tea.KeyMsg.String, or "" if the key is not mapped
+	}{
+		{bubbweb.KeyEvent{Key: "a", Code: "KeyA"}, "a"},
+		{bubbweb.KeyEvent{Key: "A", Code: "KeyA", Shift: true}, "A"},
+		{bubbweb.KeyEvent{Key: "é"}, "é"},
+		{bubbweb.KeyEvent{Key: " ", Code: "Space"}, " "},
+		{bubbweb.KeyEvent{Key: "x", Code: "KeyX", Alt: true}, "alt+x"},
+		{bubbweb.KeyEvent{Key: "a", Code: "KeyA", Repeat: true}, "a"},
+
+		{bubbweb.KeyEvent{Key: "Enter"}, "enter"},
+		{bubbweb.KeyEvent{Key: "Enter", Alt: true}, "alt+enter"},
+		{bubbweb.KeyEvent{Key: "Tab"}, "tab"},
+		{bubbweb.KeyEvent{Key: "Tab", Shift: true}, "shift+tab"},
+		{bubbweb.KeyEvent{Key: "Backspace"}, "backspace"},
+		{bubbweb.KeyEvent{Key: "Escape"}, "esc"},
+		{bubbweb.KeyEvent{Key: "Delete"}, "delete"},
+		{bubbweb.KeyEvent{Key: "ArrowUp"}, "up"},
+		{bubbweb.KeyEvent{Key: "ArrowDown", Shift: true}, "shift+down"},
+		{bubbweb.KeyEvent{Key: "ArrowLeft", Ctrl: true}, "ctrl+left"},
+		{bubbweb.KeyEvent{Key: "ArrowUp", Ctrl: true, Shift: true}, "ctrl+shift+up"},
+		{bubbweb.KeyEvent{Key: "Home", Shift: true}, "shift+home"},
+		{bubbweb.KeyEvent{Key: "PageDown", Ctrl: true}, "ctrl+pgdown"},
+		{bubbweb.KeyEvent{Key: "PageUp", Shift: true}, "pgup"},
+		{bubbweb.KeyEvent{Key: "F5"}, "f5"},
+		{bubbweb.KeyEvent{Key: "F12", Shift: true}, "f12"},
+
+		{bubbweb.KeyEvent{Key: "c", Code: "KeyC", Ctrl: true}, "ctrl+c"},
+		{bubbweb.KeyEvent{Key: "C", Code: "KeyC", Ctrl: true, Shift: true}, "ctrl+c"},
+		{bubbweb.KeyEvent{Key: "с", Code: "KeyC", Ctrl: true}, "ctrl+c"},
+		{bubbweb.KeyEvent{Key: "a", Code: "KeyA", Ctrl: true, Alt: true}, "alt+ctrl+a"},
+		{bubbweb.KeyEvent{Key: " ", Code: "Space", Ctrl: true}, "ctrl+@"},
+		{bubbweb.KeyEvent{Key: "[", Code: "BracketLeft", Ctrl: true}, "esc"},
+		{bubbweb.KeyEvent{Key: "\\", Code: "Backslash", Ctrl: true}, "ctrl+\\"},
+		{bubbweb.KeyEvent{Key: "_", Code: "Minus", Ctrl: true, Shift: true}, "ctrl+_"},
+
+		{bubbweb.KeyEvent{Key: "Shift", Code: "ShiftLeft", Shift: true}, ""},
+		{bubbweb.KeyEvent{Key: "Dead", Code: "Quote"}, ""},
+		{bubbweb.KeyEvent{Key: "Unidentified"}, ""},
+		{bubbweb.KeyEvent{Key: "v", Code: "KeyV", Meta: true}, ""},
+		{bubbweb.KeyEvent{Key: "ArrowUp", Meta: true}, ""},
+		{bubbweb.KeyEvent{Key: "1", Code: "Digit1", Ctrl: true}, ""},
+	}
+	for _, tt := range tests {
+		msg, ok := tt.event.KeyMsg()
+		got := ""
+		if ok {
+			got = msg.String()
+		}
+		if got != tt.want {
+			t.Errorf("%+v.KeyMsg() = %q, %v, want %q", tt.event, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package bubbweb
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultLinkSchemes are the URI schemes of the hyperlinks the page opens
+// unless configured otherwise.
+var DefaultLinkSchemes = []string{"http", "https", "mailto"}
+
+// LinkMsg is sent when the user clicks a hyperlink whose scheme is one of
+// Config.AppLinkSchemes, instead of the page opening it.
+type LinkMsg struct {
+	URL *url.URL
+}
+
+// Link handles a click on an OSC 8 hyperlink to uri, like bubbletea_link, and
+// reports whether the page should open it in a new tab. A link whose scheme
+// is one of Config.AppLinkSchemes is sent to the program as a LinkMsg
+// instead, and links with schemes in neither list are ignored.
+func (p *Program) Link(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if slices.Contains(p.config.AppLinkSchemes, scheme) {
+		p.Send(LinkMsg{URL: u})
+		return false
+	}
+	return slices.Contains(p.config.LinkSchemes, scheme)
+}
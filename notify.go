@@ -0,0 +1,67 @@
+package bubbweb
+
+import (
+	"errors"
+	"reflect"
+	"unsafe"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// NotificationMsg reports what became of a notification sent with Notify in
+// the browser.
+type NotificationMsg struct {
+	// Permission is the page's notification permission once the user has
+	// been asked for it: "granted", "denied" or "default" if they dismissed
+	// the prompt.
+	Permission string
+
+	// Err is set if the notification was not shown.
+	Err error
+}
+
+var (
+	// ErrNotificationsUnsupported is reported when the browser has no
+	// Notifications API.
+	ErrNotificationsUnsupported = errors.New("bubbweb: notifications not supported")
+
+	// ErrNotificationsDenied is reported when the user has not allowed the
+	// page to show notifications.
+	ErrNotificationsDenied = errors.New("bubbweb: notifications not allowed")
+)
+
+// bellMsg asks the program to ring its terminal's bell. Notify sends it
+// outside the browser, and the program's filter turns it into a write to the
+// program's own output, so the bell goes wherever the rest of the output
+// goes, recording included.
+type bellMsg struct{}
+
+// filter is the message filter of every bubbweb program. It passes messages
+// on to the filter the program was created with, if any.
+func (p *Program) filter(model tea.Model, msg tea.Msg) tea.Msg {
+	if _, ok := msg.(bellMsg); ok {
+		if p.out != nil {
+			_, _ = p.out.Write([]byte("\a"))
+		}
+		return nil
+	}
+	if p.next != nil {
+		return p.next(model, msg)
+	}
+	return msg
+}
+
+// installFilter makes filter the program's message filter, in front of any
+// the program was created with.
+//
+// A tea.Program keeps a single filter, which tea.WithFilter replaces, and has
+// no way to read it back. Rather than have a filter passed to the program
+// replace bubbweb's, which would silently stop the bell and hand bellMsg to
+// the model, it is read from the program and chained.
+func (p *Program) installFilter() {
+	field := reflect.ValueOf(p.Program).Elem().FieldByName("filter")
+	if field.IsValid() && field.Type() == reflect.TypeOf(p.next) {
+		p.next = *(*func(tea.Model, tea.Msg) tea.Msg)(unsafe.Pointer(field.UnsafeAddr()))
+	}
+	tea.WithFilter(p.filter)(p.Program)
+}
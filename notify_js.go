@@ -0,0 +1,91 @@
+//go:build js
+// +build js
+
+package bubbweb
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb/internal/jsutil"
+)
+
+// Notify returns a command that alerts the user with a notification of the
+// given title and body. In the browser it uses the Notifications API, asking
+// for permission first if need be, and reports the outcome as a
+// NotificationMsg. Elsewhere it rings the bell of the program's terminal,
+// which for a Handler session is the page's, and reports nothing.
+func Notify(title, body string) tea.Cmd {
+	return func() tea.Msg {
+		return notify(title, body)
+	}
+}
+
+// notify shows a notification, asking for permission first if the user has
+// not yet been asked. It blocks until they answer, so it must not be called
+// from a js.Func callback.
+func notify(title, body string) (msg NotificationMsg) {
+	notification := js.Global().Get("Notification")
+	if notification.Type() != js.TypeFunction {
+		return NotificationMsg{Err: ErrNotificationsUnsupported}
+	}
+
+	msg.Permission = notification.Get("permission").String()
+	if msg.Permission == "default" {
+		// Older browsers take a callback instead of returning a promise.
+		if promise := notification.Call("requestPermission"); promise.Type() == js.TypeObject {
+			if _, err := jsutil.Await(promise); err != nil {
+				msg.Err = err
+				return msg
+			}
+		}
+		msg.Permission = notification.Get("permission").String()
+	}
+	if msg.Permission != "granted" {
+		msg.Err = ErrNotificationsDenied
+		return msg
+	}
+
+	// Some browsers only show notifications through a service worker and
+	// throw here.
+	defer func() {
+		if r := recover(); r != nil {
+			msg.Err = fmt.Errorf("bubbweb: showing notification: %v", r)
+		}
+	}()
+	notification.New(title, map[string]interface{}{"body": body})
+	return msg
+}
+
+// handleNotifications shows the notifications the program requests with
+// OSC 9 ; body, as iTerm2 does, or OSC 777 ; notify ; title ; body, as
+// rxvt-unicode does.
+func (p *Program) handleNotifications() {
+	p.handleOSC(9, func(data string) {
+		// OSC 9 ; 4 reports progress in ConEmu and Windows Terminal.
+		if strings.HasPrefix(data, "4;") {
+			return
+		}
+		go notify(pageTitle(), data)
+	})
+	p.handleOSC(777, func(data string) {
+		kind, rest, _ := strings.Cut(data, ";")
+		if kind != "notify" {
+			return
+		}
+		title, body, _ := strings.Cut(rest, ";")
+		go notify(title, body)
+	})
+}
+
+// pageTitle returns the title of the page, or "" in a Web Worker, which has
+// no document.
+func pageTitle() string {
+	doc := js.Global().Get("document")
+	if doc.Type() != js.TypeObject {
+		return ""
+	}
+	return doc.Get("title").String()
+}
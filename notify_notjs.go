@@ -0,0 +1,17 @@
+//go:build !js
+// +build !js
+
+package bubbweb
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// Notify returns a command that alerts the user with a notification of the
+// given title and body. In the browser it uses the Notifications API, asking
+// for permission first if need be, and reports the outcome as a
+// NotificationMsg. Elsewhere it rings the bell of the program's terminal,
+// which for a Handler session is the page's, and reports nothing.
+func Notify(title, body string) tea.Cmd {
+	return func() tea.Msg {
+		return bellMsg{}
+	}
+}
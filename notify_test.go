@@ -0,0 +1,65 @@
+//go:build !js
+// +build !js
+
+package bubbweb_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb"
+	"github.com/tmc/bubbweb/bubbwebtest"
+)
+
+// notifyModel notifies when n is typed, and lists the types of the other
+// messages it receives.
+type notifyModel struct{ types []string }
+
+func (m notifyModel) Init() tea.Cmd { return nil }
+
+func (m notifyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "n" {
+		return m, bubbweb.Notify("title", "body")
+	}
+	m.types = append(m.types, fmt.Sprintf("%T", msg))
+	return m, nil
+}
+
+func (m notifyModel) View() string { return fmt.Sprintf("%d messages", len(m.types)) }
+
+func TestNotifyBellWithFilter(t *testing.T) {
+	// The program's own filter turns x into y.
+	filter := func(_ tea.Model, msg tea.Msg) tea.Msg {
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "x" {
+			return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}
+		}
+		return msg
+	}
+	h := bubbwebtest.New(t, notifyModel{}, bubbwebtest.WithOptions(
+		bubbweb.WithProgramOptions(tea.WithFilter(filter)),
+	))
+	h.WaitForString("messages")
+	h.Type("n")
+	h.WaitFor(func(output []byte) bool {
+		return strings.Contains(string(output), "\a")
+	})
+	h.Program().Quit()
+	m, err := h.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	for _, typ := range m.(notifyModel).types {
+		if strings.Contains(typ, "bell") {
+			t.Errorf("model received %s", typ)
+		}
+	}
+
+	h = bubbwebtest.New(t, keyModel{}, bubbwebtest.WithOptions(
+		bubbweb.WithProgramOptions(tea.WithFilter(filter)),
+	))
+	h.WaitForString("keys:")
+	h.Type("x")
+	h.WaitForString("keys: <y>")
+}
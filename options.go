@@ -0,0 +1,265 @@
+package bubbweb
+
+import (
+	"io"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// MouseMode selects which mouse events a program receives.
+type MouseMode int
+
+const (
+	// MouseNone disables mouse events.
+	MouseNone MouseMode = iota
+	// MouseCellMotion reports clicks, wheel events and drags, like
+	// tea.WithMouseCellMotion.
+	MouseCellMotion
+	// MouseAllMotion also reports motion without a button pressed, like
+	// tea.WithMouseAllMotion.
+	MouseAllMotion
+)
+
+// Config holds bubbweb's own settings, as opposed to those of the tea.Program
+// it wraps. Options modify it; DefaultConfig returns its starting point.
+//
+// Settings that only make sense in the browser, such as Namespace, are
+// accepted but ignored outside WASM so that programs build unchanged for
+// both targets.
+type Config struct {
+	// Namespace, if set, registers the program's JavaScript functions on
+	// globalThis.bubbweb.instances[Namespace] instead of the global object.
+	Namespace string
+
+	// Mouse is the mouse mode the program starts with. It defaults to
+	// MouseCellMotion in WASM and for Handler sessions, and MouseNone
+	// elsewhere.
+	Mouse MouseMode
+
+	// Polling registers bubbletea_read so pages can poll for output instead
+	// of subscribing with bubbletea_onoutput. It defaults to true.
+	Polling bool
+
+	// OutputCapacity and OutputPolicy configure the OutputBuffer the program
+	// writes to. They default to DefaultOutputCapacity and Block.
+	OutputCapacity int
+	OutputPolicy   DropPolicy
+
+	// PasteLimit is the largest paste, in bytes, the program accepts; see
+	// Program.Paste. It defaults to DefaultPasteLimit, and zero means no
+	// limit.
+	PasteLimit int
+
+	// LinkSchemes lists the URI schemes of the OSC 8 hyperlinks the page
+	// opens in a new tab when clicked. It defaults to DefaultLinkSchemes.
+	LinkSchemes []string
+
+	// AppLinkSchemes lists URI schemes internal to the program, such as
+	// "myapp", whose hyperlinks are delivered to it as a LinkMsg when
+	// clicked instead of being opened.
+	AppLinkSchemes []string
+
+	// OriginPatterns lists the hosts, besides the server's own, whose pages
+	// may connect to a Handler. See websocket.AcceptOptions.OriginPatterns.
+	// It is ignored in WASM.
+	OriginPatterns []string
+
+	// Record records the session in asciicast v2 format: the program's
+	// output and, for programs driven by a page or through NewHeadless, its
+	// input and resizes. The recording goes to RecordTo if it is set; it is
+	// kept in memory for Program.Recording if RecordTo is nil, and always in
+	// WASM, where the page retrieves it with bubbletea_recording. Handler
+	// ignores it.
+	Record   bool
+	RecordTo io.Writer
+
+	// OnExit, if set, is called once Run returns with how the program exited.
+	OnExit func(reason ExitReason, model tea.Model, err error)
+
+	// ProgramOptions are passed through to tea.NewProgram after the options
+	// bubbweb derives from the rest of the Config.
+	ProgramOptions []tea.ProgramOption
+}
+
+// DefaultConfig returns the Config that New starts from.
+func DefaultConfig() Config {
+	return Config{
+		Mouse:          defaultMouse,
+		Polling:        true,
+		OutputCapacity: DefaultOutputCapacity,
+		OutputPolicy:   Block,
+		PasteLimit:     DefaultPasteLimit,
+		LinkSchemes:    DefaultLinkSchemes,
+	}
+}
+
+// Option configures bubbweb itself, as opposed to the tea.Program it wraps.
+type Option func(*Config)
+
+func newConfig(opts []Option) *Config {
+	c := DefaultConfig()
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// programOptions returns the options to create the tea.Program with.
+func (c *Config) programOptions() []tea.ProgramOption {
+	var opts []tea.ProgramOption
+	switch c.Mouse {
+	case MouseCellMotion:
+		opts = append(opts, tea.WithMouseCellMotion())
+	case MouseAllMotion:
+		opts = append(opts, tea.WithMouseAllMotion())
+	}
+	return append(opts, c.ProgramOptions...)
+}
+
+// WithConfig sets the fields of cfg that are not zero, keeping the others as
+// DefaultConfig and the options before it left them, so that a partial
+// Config such as Config{Mouse: MouseAllMotion} changes only what it sets.
+// Options after it still apply.
+//
+// Zero values cannot be told from fields left out, so settings whose zero
+// value means something, such as MouseNone, Polling false or a PasteLimit of
+// zero, are made with their own options: WithMouse, WithPolling and
+// WithPasteLimit.
+func WithConfig(cfg Config) Option {
+	return func(c *Config) {
+		if cfg.Namespace != "" {
+			c.Namespace = cfg.Namespace
+		}
+		if cfg.Mouse != MouseNone {
+			c.Mouse = cfg.Mouse
+		}
+		if cfg.Polling {
+			c.Polling = true
+		}
+		if cfg.OutputCapacity != 0 {
+			c.OutputCapacity = cfg.OutputCapacity
+		}
+		if cfg.OutputPolicy != Block {
+			c.OutputPolicy = cfg.OutputPolicy
+		}
+		if cfg.PasteLimit != 0 {
+			c.PasteLimit = cfg.PasteLimit
+		}
+		if cfg.LinkSchemes != nil {
+			c.LinkSchemes = cfg.LinkSchemes
+		}
+		if cfg.AppLinkSchemes != nil {
+			c.AppLinkSchemes = cfg.AppLinkSchemes
+		}
+		if cfg.OriginPatterns != nil {
+			c.OriginPatterns = cfg.OriginPatterns
+		}
+		if cfg.Record {
+			c.Record = true
+		}
+		if cfg.RecordTo != nil {
+			c.RecordTo = cfg.RecordTo
+		}
+		if cfg.OnExit != nil {
+			c.OnExit = cfg.OnExit
+		}
+		if cfg.ProgramOptions != nil {
+			c.ProgramOptions = cfg.ProgramOptions
+		}
+	}
+}
+
+// WithNamespace registers the program's JavaScript functions on
+// globalThis.bubbweb.instances[name] instead of the global object, so several
+// programs can share a page. The functions keep their bubbletea_ names.
+//
+// It has no effect outside WASM.
+func WithNamespace(name string) Option {
+	return func(c *Config) {
+		c.Namespace = name
+	}
+}
+
+// WithMouse sets the mouse mode the program starts with.
+func WithMouse(mode MouseMode) Option {
+	return func(c *Config) {
+		c.Mouse = mode
+	}
+}
+
+// WithPolling controls whether bubbletea_read is registered.
+//
+// It has no effect outside WASM.
+func WithPolling(enabled bool) Option {
+	return func(c *Config) {
+		c.Polling = enabled
+	}
+}
+
+// WithOutputBuffer sets the capacity and DropPolicy of the program's output
+// buffer.
+//
+// It has no effect outside WASM.
+func WithOutputBuffer(capacity int, policy DropPolicy) Option {
+	return func(c *Config) {
+		c.OutputCapacity = capacity
+		c.OutputPolicy = policy
+	}
+}
+
+// WithPasteLimit sets the largest paste, in bytes, the program accepts, or
+// removes the limit if n is zero.
+func WithPasteLimit(n int) Option {
+	return func(c *Config) {
+		c.PasteLimit = n
+	}
+}
+
+// WithLinkSchemes sets the URI schemes of the hyperlinks the page opens,
+// replacing DefaultLinkSchemes.
+func WithLinkSchemes(schemes ...string) Option {
+	return func(c *Config) {
+		c.LinkSchemes = schemes
+	}
+}
+
+// WithAppLinkSchemes delivers clicks on hyperlinks with the given URI schemes
+// to the program as LinkMsg values.
+func WithAppLinkSchemes(schemes ...string) Option {
+	return func(c *Config) {
+		c.AppLinkSchemes = append(c.AppLinkSchemes, schemes...)
+	}
+}
+
+// WithOriginPatterns allows pages served from hosts matching patterns to
+// connect to a Handler.
+//
+// It has no effect in WASM.
+func WithOriginPatterns(patterns ...string) Option {
+	return func(c *Config) {
+		c.OriginPatterns = append(c.OriginPatterns, patterns...)
+	}
+}
+
+// WithRecording records the session in asciicast v2 format to w, or to
+// memory if w is nil. See Config.Record.
+func WithRecording(w io.Writer) Option {
+	return func(c *Config) {
+		c.Record = true
+		c.RecordTo = w
+	}
+}
+
+// WithOnExit sets a function to call once Run returns.
+func WithOnExit(fn func(reason ExitReason, model tea.Model, err error)) Option {
+	return func(c *Config) {
+		c.OnExit = fn
+	}
+}
+
+// WithProgramOptions passes options through to tea.NewProgram.
+func WithProgramOptions(opts ...tea.ProgramOption) Option {
+	return func(c *Config) {
+		c.ProgramOptions = append(c.ProgramOptions, opts...)
+	}
+}
@@ -0,0 +1,21 @@
+package bubbweb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tmc/bubbweb"
+)
+
+func TestWithConfigKeepsOmittedFields(t *testing.T) {
+	prog := bubbweb.NewHeadless(eventModel{},
+		bubbweb.WithConfig(bubbweb.Config{Mouse: bubbweb.MouseAllMotion}))
+	defer prog.Kill()
+
+	if prog.Paste(strings.Repeat("x", bubbweb.DefaultPasteLimit+1)) {
+		t.Error("paste over DefaultPasteLimit accepted after WithConfig without a PasteLimit")
+	}
+	if !prog.Paste("x") {
+		t.Error("paste rejected")
+	}
+}
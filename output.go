@@ -0,0 +1,168 @@
+package bubbweb
+
+import (
+	"io"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tmc/bubbweb/internal/utf8util"
+)
+
+// DefaultOutputCapacity is the capacity of the output buffer bubbweb creates
+// for a program.
+const DefaultOutputCapacity = 1 << 20
+
+// DropPolicy determines what an OutputBuffer does with a write that does not
+// fit in its remaining capacity.
+type DropPolicy int
+
+const (
+	// Block makes the writer wait until a reader drains enough of the buffer.
+	// This applies backpressure to the program's renderer.
+	Block DropPolicy = iota
+	// DropNewest discards the part of the write that does not fit.
+	DropNewest
+	// DropOldest discards the oldest buffered output to make room for the write.
+	DropOldest
+)
+
+// OutputBuffer is a bounded, goroutine-safe sink for program output.
+//
+// The program's renderer writes to it while the page drains it, and the
+// policy decides what happens when the page falls behind. Bytes discarded by
+// the policy are counted and reported by Dropped.
+type OutputBuffer struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	buf      []byte
+	capacity int
+	policy   DropPolicy
+	dropped  uint64
+	closed   bool
+
+	// notify, if set, is called without the lock held whenever new output
+	// becomes available.
+	notify func()
+}
+
+// NewOutputBuffer returns an OutputBuffer holding at most capacity bytes.
+// A capacity of zero or less selects DefaultOutputCapacity, and one below
+// utf8.UTFMax is raised to it: Drain holds back an incomplete rune, so a
+// smaller buffer could fill up with one and never drain.
+func NewOutputBuffer(capacity int, policy DropPolicy) *OutputBuffer {
+	if capacity <= 0 {
+		capacity = DefaultOutputCapacity
+	}
+	capacity = max(capacity, utf8.UTFMax)
+	b := &OutputBuffer{capacity: capacity, policy: policy}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Write appends p to the buffer according to its DropPolicy.
+// It reports len(p) even when the policy discards bytes, so renderers never
+// see a short write. Writing to a closed buffer returns io.ErrClosedPipe.
+func (b *OutputBuffer) Write(p []byte) (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	switch b.policy {
+	case Block:
+		for len(p) > 0 {
+			for len(b.buf) == b.capacity && !b.closed {
+				b.signal()
+				b.cond.Wait()
+			}
+			if b.closed {
+				return n, io.ErrClosedPipe
+			}
+			chunk := min(len(p), b.capacity-len(b.buf))
+			b.buf = append(b.buf, p[:chunk]...)
+			p = p[chunk:]
+			n += chunk
+		}
+	case DropNewest:
+		n = len(p)
+		if free := b.capacity - len(b.buf); len(p) > free {
+			b.dropped += uint64(len(p) - free)
+			p = p[:free]
+		}
+		b.buf = append(b.buf, p...)
+	case DropOldest:
+		n = len(p)
+		if len(p) > b.capacity {
+			b.dropped += uint64(len(p) - b.capacity)
+			p = p[len(p)-b.capacity:]
+		}
+		if over := len(b.buf) + len(p) - b.capacity; over > 0 {
+			b.dropped += uint64(over)
+			b.buf = append(b.buf[:0], b.buf[over:]...)
+		}
+		b.buf = append(b.buf, p...)
+	}
+
+	b.signal()
+	return n, nil
+}
+
+// signal calls the notify hook with the lock temporarily released.
+// The caller must hold b.mu.
+func (b *OutputBuffer) signal() {
+	if b.notify == nil || len(b.buf) == 0 {
+		return
+	}
+	b.mu.Unlock()
+	b.notify()
+	b.mu.Lock()
+}
+
+// Drain removes and returns everything in the buffer, or nil if it is empty.
+//
+// While the buffer is open, an incomplete UTF-8 sequence at the end is held
+// back until the rest of it is written, so a multi-byte rune is never split
+// across two drains. Once the buffer is closed Drain returns everything.
+func (b *OutputBuffer) Drain() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(b.buf)
+	if !b.closed {
+		n -= utf8util.Incomplete(b.buf)
+	}
+	if n == 0 {
+		return nil
+	}
+	data := b.buf[:n:n]
+	b.buf = append(make([]byte, 0, len(b.buf)), b.buf[n:]...)
+	b.cond.Broadcast()
+	return data
+}
+
+// Len returns the number of buffered bytes.
+func (b *OutputBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.buf)
+}
+
+// Dropped returns the number of bytes discarded by the DropPolicy so far.
+func (b *OutputBuffer) Dropped() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// Close stops the buffer from accepting writes and unblocks any writer
+// waiting for space. Buffered output can still be drained.
+func (b *OutputBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
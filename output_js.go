@@ -0,0 +1,136 @@
+//go:build js
+// +build js
+
+package bubbweb
+
+import (
+	"syscall/js"
+)
+
+// pusher delivers a program's output to JavaScript. Output stays in the
+// OutputBuffer for bubbletea_read until a page subscribes with
+// bubbletea_onoutput; from then on it is pushed to the subscriber at most
+// once per animation frame.
+//
+// Browsers stop running animation frames in hidden tabs, so there output is
+// pushed from a timer instead, and a frame still pending when the tab is
+// hidden is replaced by an immediate push. A full buffer is pushed at once
+// rather than waiting for either: with the Block policy the renderer waits
+// for room while holding its lock, and a page callback that sends the
+// program a message would then block the only JavaScript thread.
+//
+// WASM runs on a single thread and pusher never blocks, so its fields are
+// only touched between goroutine switches and need no locking.
+type pusher struct {
+	out       *OutputBuffer
+	callback  js.Value
+	scheduled bool
+	frame     bool // scheduled with requestAnimationFrame, not a timer
+	closed    bool
+	timer     js.Value
+	flushFunc js.Func
+	onHidden  js.Func
+}
+
+func newPusher(out *OutputBuffer) *pusher {
+	p := &pusher{out: out, callback: js.Null()}
+	p.flushFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		p.flush()
+		return nil
+	})
+	p.onHidden = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if p.scheduled && p.frame && hidden() {
+			p.cancel()
+			p.flush()
+		}
+		return nil
+	})
+	if doc := js.Global().Get("document"); doc.Type() == js.TypeObject {
+		doc.Call("addEventListener", "visibilitychange", p.onHidden)
+	}
+	out.notify = p.schedule
+	return p
+}
+
+// hidden reports whether the page is hidden, in which case the browser does
+// not run animation frames. Workers have no page and are never hidden, but
+// they have no animation frames either.
+func hidden() bool {
+	doc := js.Global().Get("document")
+	return doc.Type() == js.TypeObject && doc.Get("hidden").Truthy()
+}
+
+// subscribe sets the JavaScript function that receives output.
+// A null or undefined callback unsubscribes, leaving output to bubbletea_read.
+func (p *pusher) subscribe(callback js.Value) {
+	if callback.Type() != js.TypeFunction {
+		callback = js.Null()
+	}
+	p.callback = callback
+	p.schedule()
+}
+
+// schedule arranges for flush to run on the next animation frame, or right
+// away if the buffer is full. Writes made before then are coalesced into a
+// single delivery.
+func (p *pusher) schedule() {
+	if p.closed || p.callback.IsNull() {
+		return
+	}
+	if p.out.Len() >= p.out.capacity {
+		p.cancel()
+		p.flush()
+		return
+	}
+	if p.scheduled {
+		return
+	}
+	p.scheduled = true
+
+	// requestAnimationFrame is unavailable in workers and paused in hidden
+	// pages; fall back to a timer.
+	if raf := js.Global().Get("requestAnimationFrame"); raf.Type() == js.TypeFunction && !hidden() {
+		p.frame = true
+		p.timer = raf.Invoke(p.flushFunc)
+	} else {
+		p.frame = false
+		p.timer = js.Global().Call("setTimeout", p.flushFunc, 0)
+	}
+}
+
+// cancel cancels a pending delivery, if any.
+func (p *pusher) cancel() {
+	if !p.scheduled {
+		return
+	}
+	if p.frame {
+		js.Global().Call("cancelAnimationFrame", p.timer)
+	} else {
+		js.Global().Call("clearTimeout", p.timer)
+	}
+	p.scheduled = false
+}
+
+// close delivers any remaining output, cancels a pending delivery and
+// releases the JavaScript functions. The pusher must not be used afterwards.
+func (p *pusher) close() {
+	p.cancel()
+	p.flush()
+	p.closed = true
+	if doc := js.Global().Get("document"); doc.Type() == js.TypeObject {
+		doc.Call("removeEventListener", "visibilitychange", p.onHidden)
+	}
+	p.onHidden.Release()
+	p.flushFunc.Release()
+}
+
+// flush delivers everything buffered so far to the subscriber.
+func (p *pusher) flush() {
+	p.scheduled = false
+	if p.callback.IsNull() {
+		return
+	}
+	if data := p.out.Drain(); len(data) > 0 {
+		p.callback.Invoke(bytesToJS(data))
+	}
+}
@@ -0,0 +1,143 @@
+package bubbweb_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tmc/bubbweb"
+)
+
+func TestOutputBufferPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  bubbweb.DropPolicy
+		writes  []string
+		want    string
+		dropped uint64
+	}{
+		{"DropNewest fits", bubbweb.DropNewest, []string{"abc", "de"}, "abcde", 0},
+		{"DropNewest to capacity", bubbweb.DropNewest, []string{"abcd", "efgh"}, "abcdefgh", 0},
+		{"DropNewest overflow", bubbweb.DropNewest, []string{"abcdef", "ghij"}, "abcdefgh", 2},
+		{"DropNewest full", bubbweb.DropNewest, []string{"abcdefgh", "ij"}, "abcdefgh", 2},
+		{"DropOldest overflow", bubbweb.DropOldest, []string{"abcdef", "ghij"}, "cdefghij", 2},
+		{"DropOldest oversized write", bubbweb.DropOldest, []string{"ab", "cdefghijkl"}, "efghijkl", 4},
+		{"Block fits", bubbweb.Block, []string{"abcdefgh"}, "abcdefgh", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bubbweb.NewOutputBuffer(8, tt.policy)
+			for _, w := range tt.writes {
+				if n, err := b.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v, want %d, nil", w, n, err, len(w))
+				}
+			}
+			if got := b.Len(); got != len(tt.want) {
+				t.Errorf("Len() = %d, want %d", got, len(tt.want))
+			}
+			if got := string(b.Drain()); got != tt.want {
+				t.Errorf("Drain() = %q, want %q", got, tt.want)
+			}
+			if got := b.Dropped(); got != tt.dropped {
+				t.Errorf("Dropped() = %d, want %d", got, tt.dropped)
+			}
+			if got := b.Drain(); got != nil {
+				t.Errorf("second Drain() = %q, want nil", got)
+			}
+		})
+	}
+}
+
+func TestOutputBufferBlock(t *testing.T) {
+	b := bubbweb.NewOutputBuffer(4, bubbweb.Block)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Write([]byte("abcdefghij"))
+	}()
+
+	var got []byte
+	deadline := time.After(5 * time.Second)
+	for len(got) < 10 {
+		select {
+		case <-deadline:
+			t.Fatalf("drained %q before timing out", got)
+		default:
+		}
+		got = append(got, b.Drain()...)
+		time.Sleep(time.Millisecond)
+	}
+	<-done
+	if string(got) != "abcdefghij" {
+		t.Errorf("drained %q, want %q", got, "abcdefghij")
+	}
+	if b.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0", b.Dropped())
+	}
+}
+
+func TestOutputBufferClose(t *testing.T) {
+	b := bubbweb.NewOutputBuffer(4, bubbweb.Block)
+	errc := make(chan error)
+	go func() {
+		_, err := b.Write([]byte("abcdef"))
+		errc <- err
+	}()
+	for b.Len() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Close()
+	if err := <-errc; err != io.ErrClosedPipe {
+		t.Errorf("blocked Write returned %v after Close, want io.ErrClosedPipe", err)
+	}
+	if got := string(b.Drain()); got != "abcd" {
+		t.Errorf("Drain() after Close = %q, want %q", got, "abcd")
+	}
+	if _, err := b.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Errorf("Write after Close returned %v, want io.ErrClosedPipe", err)
+	}
+}
+
+func TestOutputBufferDrainUTF8(t *testing.T) {
+	b := bubbweb.NewOutputBuffer(0, bubbweb.Block)
+	b.Write([]byte("a\xe7\x95"))
+	if got := string(b.Drain()); got != "a" {
+		t.Errorf("Drain() = %q, want the partial rune held back", got)
+	}
+	b.Write([]byte("\x8c"))
+	if got := string(b.Drain()); got != "界" {
+		t.Errorf("Drain() = %q, want %q", got, "界")
+	}
+
+	b.Write([]byte("\xe7"))
+	b.Close()
+	if got := b.Drain(); !bytes.Equal(got, []byte("\xe7")) {
+		t.Errorf("Drain() after Close = %q, want the partial rune", got)
+	}
+}
+
+func TestOutputBufferSmallCapacity(t *testing.T) {
+	b := bubbweb.NewOutputBuffer(1, bubbweb.Block)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Write([]byte("界界"))
+	}()
+
+	var got []byte
+	deadline := time.After(5 * time.Second)
+	for len(got) < len("界界") {
+		select {
+		case <-deadline:
+			t.Fatalf("drained %q before timing out", got)
+		default:
+		}
+		got = append(got, b.Drain()...)
+		time.Sleep(time.Millisecond)
+	}
+	<-done
+	if string(got) != "界界" {
+		t.Errorf("drained %q, want %q", got, "界界")
+	}
+}
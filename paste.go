@@ -0,0 +1,55 @@
+package bubbweb
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb/vt"
+)
+
+// DefaultPasteLimit is the largest paste, in bytes, a program accepts unless
+// configured otherwise.
+const DefaultPasteLimit = 1 << 20
+
+// pasteChunk is the most text delivered in one paste message. Larger pastes
+// arrive as several, so no single update has to handle all of a huge one.
+const pasteChunk = 64 << 10
+
+// Paste sends text to the program as pasted from the clipboard, like
+// bubbletea_paste, and reports whether it was accepted.
+//
+// If the program has enabled bracketed paste, as bubbletea does by default,
+// the text arrives as tea.KeyMsg values of type tea.KeyRunes with Paste set,
+// split into several if it is long, with line breaks as "\n". Otherwise it is
+// typed as input, with line breaks as Enter, as a terminal would.
+//
+// Pastes longer than Config.PasteLimit are dropped.
+func (p *Program) Paste(text string) bool {
+	if p.input == nil || text == "" {
+		return false
+	}
+	if limit := p.config.PasteLimit; limit > 0 && len(text) > limit {
+		return false
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	if !p.screen.Mode(vt.ModeBracketedPaste) {
+		p.Input([]byte(strings.ReplaceAll(text, "\n", "\r")))
+		return true
+	}
+
+	if p.recording != nil {
+		p.recording.Input([]byte("\x1b[200~" + text + "\x1b[201~"))
+	}
+	for text != "" {
+		n := min(len(text), pasteChunk)
+		for n < len(text) && !utf8.RuneStart(text[n]) {
+			n--
+		}
+		p.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text[:n]), Paste: true})
+		text = text[n:]
+	}
+	return true
+}
@@ -0,0 +1,258 @@
+package bubbweb
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb/vt"
+)
+
+// Program is a BubbleTea program wired up by bubbweb.
+//
+// It embeds *tea.Program, whose methods such as Send and Quit it promotes,
+// and overrides Run and Kill to release the resources bubbweb attached to
+// the program once it exits. It is not itself a *tea.Program: code that
+// needs one can use the Program field, but should still call Run and Kill
+// on the bubbweb program.
+type Program struct {
+	*tea.Program
+
+	config    *Config
+	input     *MinReadBuffer
+	output    *OutputBuffer
+	screen    *vt.Screen
+	recording *recording
+
+	// out is where the program's output goes, terminal or otherwise.
+	out io.Writer
+
+	// next is the message filter the program was created with, which
+	// filter passes messages on to.
+	next func(tea.Model, tea.Msg) tea.Msg
+
+	// osc holds the functions that handle OSC sequences in the output, by
+	// command number. It is only modified before the program runs.
+	osc map[int]func(data string)
+
+	// onExit, if set, is called once Run returns, before Config.OnExit.
+	onExit func(model tea.Model, err error)
+}
+
+// NewProgram creates a new BubbleTea program configured for the current
+// platform, wired up as New(model, WithProgramOptions(options...)) wires it.
+//
+// It returns the *tea.Program itself, whose Run does not do what Program.Run
+// does once the program exits: the page is not told how it exited and
+// Config.OnExit is not called. Programs that need either use New.
+func NewProgram(model tea.Model, options ...tea.ProgramOption) *tea.Program {
+	return New(model, WithProgramOptions(options...)).Program
+}
+
+// newPipedProgram creates a program that reads its input from and writes its
+// output to in-memory buffers instead of a terminal, so that it can be driven
+// by a page. Options are applied before those derived from cfg.
+func newPipedProgram(model tea.Model, cfg *Config, options ...tea.ProgramOption) *Program {
+	input := &MinReadBuffer{}
+	output := NewOutputBuffer(cfg.OutputCapacity, cfg.OutputPolicy)
+	rec := newRecording(cfg, runtime.GOOS == "js")
+
+	screen := vt.New(defaultWidth, defaultHeight)
+	out := pipedOutput{out: output, screen: screen, rec: rec}
+
+	p := &Program{
+		config:    cfg,
+		input:     input,
+		output:    output,
+		screen:    screen,
+		recording: rec,
+		out:       out,
+		osc:       map[int]func(string){},
+	}
+
+	// Combine default options with user-provided options
+	allOptions := append([]tea.ProgramOption{
+		tea.WithInput(input),
+		tea.WithOutput(out),
+	}, options...)
+	allOptions = append(allOptions, cfg.programOptions()...)
+	p.Program = tea.NewProgram(model, allOptions...)
+	p.installFilter()
+
+	screen.OnOSC(func(cmd int, data string) {
+		if fn := p.osc[cmd]; fn != nil {
+			fn(data)
+		}
+	})
+	return p
+}
+
+// handleOSC calls fn with the data of each OSC sequence with command number
+// cmd the program writes. It is called while the program writes, so it must
+// not block; in particular it must not call Send other than from a new
+// goroutine.
+func (p *Program) handleOSC(cmd int, fn func(data string)) {
+	p.osc[cmd] = fn
+}
+
+// Terminal size a piped program's screen starts with, until the page
+// reports its own.
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Largest terminal size Resize accepts. The page reports its size, so on a
+// server an unbounded one would let any client make it allocate the screen.
+const (
+	maxWidth  = 500
+	maxHeight = 200
+)
+
+// pipedOutput passes a piped program's output on to the page, and to the
+// screen and recording that follow it.
+type pipedOutput struct {
+	out    *OutputBuffer
+	screen *vt.Screen
+	rec    *recording
+}
+
+func (w pipedOutput) Write(p []byte) (int, error) {
+	w.screen.Write(p)
+	if w.rec != nil {
+		w.rec.Output(p)
+	}
+	return w.out.Write(p)
+}
+
+// NewHeadless creates a program driven entirely from Go, on any platform.
+// It is wired up exactly as New wires a program in WASM, but instead of
+// registering JavaScript functions it leaves the program to be driven through
+// Input, Resize and Mouse and its output to be drained from Output. It is
+// meant for tests; see the bubbwebtest package.
+func NewHeadless(model tea.Model, opts ...Option) *Program {
+	return newPipedProgram(model, newConfig(opts), tea.WithoutSignalHandler())
+}
+
+// Input feeds data to the program as if it had been typed at the terminal,
+// like bubbletea_write. It does nothing for a program using the terminal.
+func (p *Program) Input(data []byte) {
+	if p.input != nil {
+		if p.recording != nil {
+			p.recording.Input(data)
+		}
+		p.input.Write(data)
+	}
+}
+
+// Output returns the buffer the program writes to, or nil for a program
+// using the terminal.
+func (p *Program) Output() *OutputBuffer {
+	return p.output
+}
+
+// Screen returns the screen the program's output has drawn, or nil for a
+// program using the terminal. It follows Resize, and must only be inspected,
+// not written to.
+func (p *Program) Screen() *vt.Screen {
+	return p.screen
+}
+
+// Resize tells the program the terminal is now width columns by height rows,
+// like bubbletea_resize. The size is clamped to between 1x1 and 500x200.
+func (p *Program) Resize(width, height int) {
+	width, height = min(max(width, 1), maxWidth), min(max(height, 1), maxHeight)
+	if p.recording != nil {
+		p.recording.Resize(width, height)
+	}
+	if p.screen != nil {
+		p.screen.Resize(width, height)
+	}
+	p.Send(tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Mouse sends a mouse event to the program, like bubbletea_mouse.
+//
+// A recording records the event as the SGR mouse report a terminal sends
+// for it.
+func (p *Program) Mouse(msg tea.MouseMsg) {
+	if p.recording != nil {
+		p.recording.Input(mouseSequence(msg))
+	}
+	p.Send(msg)
+}
+
+// mouseSequence returns the SGR mouse report, CSI < b ; x ; y M, a terminal
+// sends for msg. Releases end in m instead of M.
+func mouseSequence(msg tea.MouseMsg) []byte {
+	var b int
+	switch {
+	case msg.Button == tea.MouseButtonNone:
+		b = 3
+	case msg.Button >= tea.MouseButtonBackward:
+		b = 128 + int(msg.Button-tea.MouseButtonBackward)
+	case tea.MouseEvent(msg).IsWheel():
+		b = 64 + int(msg.Button-tea.MouseButtonWheelUp)
+	default:
+		b = int(msg.Button - tea.MouseButtonLeft)
+	}
+	if msg.Shift {
+		b |= 4
+	}
+	if msg.Alt {
+		b |= 8
+	}
+	if msg.Ctrl {
+		b |= 16
+	}
+	if msg.Action == tea.MouseActionMotion {
+		b |= 32
+	}
+	final := 'M'
+	if msg.Action == tea.MouseActionRelease {
+		final = 'm'
+	}
+	return fmt.Appendf(nil, "\x1b[<%d;%d;%d%c", b, msg.X+1, msg.Y+1, final)
+}
+
+// Run runs the program, blocking until it exits. See [tea.Program.Run].
+func (p *Program) Run() (model tea.Model, err error) {
+	defer func() {
+		// Report panics that tea was told not to catch before passing them on.
+		r := recover()
+		p.close()
+		if p.onExit != nil {
+			p.onExit(model, err)
+		}
+		if p.config.OnExit != nil {
+			p.config.OnExit(exitReason(model, err), model, err)
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+	return p.Program.Run()
+}
+
+// Kill stops the program immediately. See [tea.Program.Kill].
+func (p *Program) Kill() {
+	// Close first so a renderer blocked on a full output buffer lets go of
+	// the lock Kill needs.
+	p.close()
+	p.Program.Kill()
+}
+
+// close unblocks any pending input read so the program's read loop can exit,
+// and any write waiting for room in the output buffer.
+func (p *Program) close() {
+	if p.input != nil {
+		p.input.Close()
+	}
+	if p.output != nil {
+		p.output.Close()
+	}
+	if p.recording != nil {
+		p.recording.Close()
+	}
+}
@@ -0,0 +1,69 @@
+package bubbweb
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/tmc/bubbweb/asciicast"
+)
+
+// recording records a program's session in asciicast v2 format.
+type recording struct {
+	*asciicast.Writer
+
+	// memory holds a copy of the recording when it is kept for Recording,
+	// or is nil.
+	memory *lockedBuffer
+}
+
+// newRecording returns the recording cfg asks for, or nil if it asks for
+// none. The recording is kept in memory if keep is set or if it has nowhere
+// else to go.
+func newRecording(cfg *Config, keep bool) *recording {
+	if !cfg.Record {
+		return nil
+	}
+	r := &recording{}
+	w := cfg.RecordTo
+	if keep || w == nil {
+		r.memory = &lockedBuffer{}
+		if w == nil {
+			w = r.memory
+		} else {
+			w = io.MultiWriter(w, r.memory)
+		}
+	}
+	r.Writer = asciicast.NewWriter(w, asciicast.Header{
+		Env: map[string]string{"TERM": "xterm-256color"},
+	})
+	return r
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// Bytes returns a copy of the buffer's contents.
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
+
+// Recording returns the session recorded so far in asciicast v2 format, if
+// it is being recorded to memory, or nil. See WithRecording.
+func (p *Program) Recording() []byte {
+	if p.recording == nil || p.recording.memory == nil {
+		return nil
+	}
+	return p.recording.memory.Bytes()
+}
@@ -0,0 +1,111 @@
+package bubbweb_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tmc/bubbweb"
+	"github.com/tmc/bubbweb/asciicast"
+	"github.com/tmc/bubbweb/bubbwebtest"
+)
+
+// eventModel lists the keys and mouse events it has received.
+type eventModel struct{ events []string }
+
+func (m eventModel) Init() tea.Cmd { return nil }
+
+func (m eventModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.events = append(m.events, msg.String())
+	case tea.MouseMsg:
+		m.events = append(m.events, msg.String())
+	}
+	return m, nil
+}
+
+func (m eventModel) View() string {
+	if len(m.events) == 0 {
+		return "no events"
+	}
+	return fmt.Sprintf("event %d: %s", len(m.events), m.events[len(m.events)-1])
+}
+
+// TestRecordKeysAndMouse checks that keys and mouse events are recorded as
+// input that a terminal program reads back as the same events.
+func TestRecordKeysAndMouse(t *testing.T) {
+	events := []any{
+		bubbweb.KeyEvent{Key: "a", Code: "KeyA"},
+		bubbweb.KeyEvent{Key: "é"},
+		bubbweb.KeyEvent{Key: " ", Code: "Space"},
+		bubbweb.KeyEvent{Key: "x", Code: "KeyX", Alt: true},
+		bubbweb.KeyEvent{Key: "c", Code: "KeyC", Ctrl: true},
+		bubbweb.KeyEvent{Key: "Enter"},
+		bubbweb.KeyEvent{Key: "Escape"},
+		bubbweb.KeyEvent{Key: "Tab", Shift: true},
+		bubbweb.KeyEvent{Key: "ArrowUp", Ctrl: true, Shift: true},
+		bubbweb.KeyEvent{Key: "ArrowLeft", Alt: true},
+		bubbweb.KeyEvent{Key: "PageDown", Ctrl: true},
+		bubbweb.KeyEvent{Key: "F5"},
+		bubbweb.KeyEvent{Key: "F20"},
+		tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonLeft, X: 3, Y: 4},
+		tea.MouseMsg{Action: tea.MouseActionMotion, Button: tea.MouseButtonLeft, X: 5, Y: 4},
+		tea.MouseMsg{Action: tea.MouseActionRelease, Button: tea.MouseButtonLeft, X: 5, Y: 4},
+		tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelUp, Ctrl: true},
+		tea.MouseMsg{Action: tea.MouseActionMotion, Button: tea.MouseButtonNone, X: 10, Y: 2, Shift: true},
+	}
+
+	h := bubbwebtest.New(t, eventModel{}, bubbwebtest.WithOptions(bubbweb.WithRecording(nil)))
+	h.WaitForString("no events")
+	for _, e := range events {
+		switch e := e.(type) {
+		case bubbweb.KeyEvent:
+			h.Program().Key(e)
+		case tea.MouseMsg:
+			h.Program().Mouse(e)
+		}
+	}
+	h.Program().Quit()
+	m, err := h.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	want := m.(eventModel).events
+	if len(want) != len(events) {
+		t.Fatalf("program received %q, want %d events", want, len(events))
+	}
+
+	r, err := asciicast.NewReader(bytes.NewReader(h.Program().Recording()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input []string
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Type == asciicast.Input {
+			input = append(input, e.Data)
+		}
+	}
+	if len(input) != len(events) {
+		t.Fatalf("recorded input %q, want %d events", input, len(events))
+	}
+
+	// Type each recorded event into a new program on its own, so that
+	// consecutive ones are not read as one.
+	replay := bubbwebtest.New(t, eventModel{})
+	replay.WaitForString("no events")
+	for i, data := range input {
+		replay.Type(data)
+		replay.WaitForString(fmt.Sprintf("event %d: %s", i+1, want[i]))
+	}
+}
@@ -0,0 +1,252 @@
+//go:build !js
+// +build !js
+
+package bubbweb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/coder/websocket"
+	"github.com/muesli/termenv"
+)
+
+// Session describes a browser connection served by Handler.
+type Session struct {
+	// Request is the HTTP request that opened the connection.
+	Request *http.Request
+
+	ctx      context.Context
+	renderer *lipgloss.Renderer
+}
+
+// Context returns a context that is canceled when the connection closes.
+func (s Session) Context() context.Context {
+	return s.ctx
+}
+
+// Renderer returns a lipgloss renderer for the session's terminal. The
+// default renderer describes the server's own stdout, so styles for the page
+// should be created with Renderer().NewStyle() instead of lipgloss.NewStyle().
+func (s Session) Renderer() *lipgloss.Renderer {
+	return s.renderer
+}
+
+// serverMessage is a control message exchanged as JSON over the WebSocket.
+// The page sends the same requests it would make through the WASM bridge;
+// input may also be sent as binary messages, and output is always binary.
+type serverMessage struct {
+	Type string `json:"type"`
+
+	// write, paste
+	Data string `json:"data,omitempty"`
+
+	// focus
+	Focused bool `json:"focused,omitempty"`
+
+	// link
+	URL string `json:"url,omitempty"`
+
+	// links
+	Schemes    []string `json:"schemes,omitempty"`
+	AppSchemes []string `json:"appSchemes,omitempty"`
+
+	// resize
+	Cols int `json:"cols,omitempty"`
+	Rows int `json:"rows,omitempty"`
+
+	// mouse
+	Action tea.MouseAction `json:"action,omitempty"`
+	Button tea.MouseButton `json:"button,omitempty"`
+	X      int             `json:"x,omitempty"`
+	Y      int             `json:"y,omitempty"`
+
+	// key, with the modifiers shared with mouse
+	Key    string `json:"key,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Alt    bool   `json:"alt,omitempty"`
+	Ctrl   bool   `json:"ctrl,omitempty"`
+	Shift  bool   `json:"shift,omitempty"`
+	Meta   bool   `json:"meta,omitempty"`
+	Repeat bool   `json:"repeat,omitempty"`
+
+	// exit
+	Reason ExitReason `json:"reason,omitempty"`
+	Error  *string    `json:"error,omitempty"`
+	Model  any        `json:"model,omitempty"`
+}
+
+type handler struct {
+	newModel func(sess Session) tea.Model
+	opts     []Option
+}
+
+// Handler returns an http.Handler that serves a BubbleTea program to the
+// bubbweb page over a WebSocket. Each connection gets its own model from
+// newModel, run on the server, so programs that need the filesystem or a
+// database can be delivered through the browser without compiling to WASM.
+//
+// The page sends input as binary messages, or as JSON objects of type
+// "write", "paste", "focus", "link", "resize", "mouse", "key", "quit" and
+// "kill" mirroring the bridge functions. The server first sends a JSON
+// object of type "links" with the link schemes the page may open and those
+// to send back, then output as binary messages, followed by a JSON object of
+// type "exit" with the reason, error and model summary when the program
+// exits.
+//
+// Like programs in WASM, and unlike those using the terminal, sessions
+// default to MouseCellMotion, since the page always reports the mouse.
+func Handler(newModel func(sess Session) tea.Model, opts ...Option) http.Handler {
+	opts = append([]Option{WithMouse(MouseCellMotion)}, opts...)
+	return &handler{newModel: newModel, opts: opts}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cfg := newConfig(h.opts)
+	// Concurrent sessions would interleave in a shared recording.
+	cfg.Record = false
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: cfg.OriginPatterns,
+	})
+	if err != nil {
+		// Accept has already replied with an error.
+		return
+	}
+	defer conn.CloseNow()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	sess := Session{
+		Request:  r,
+		ctx:      ctx,
+		renderer: lipgloss.NewRenderer(io.Discard, termenv.WithProfile(termenv.TrueColor)),
+	}
+	prog := newPipedProgram(h.newModel(sess), cfg,
+		tea.WithContext(ctx),
+		tea.WithoutSignalHandler(),
+	)
+
+	ready := make(chan struct{}, 1)
+	prog.output.notify = func() {
+		select {
+		case ready <- struct{}{}:
+		default:
+		}
+	}
+
+	// Tell the page which hyperlinks to open and which to send back, since
+	// it must decide while handling the click.
+	links := serverMessage{Type: "links", Schemes: cfg.LinkSchemes, AppSchemes: cfg.AppLinkSchemes}
+	if data, err := json.Marshal(links); err == nil {
+		if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
+			return
+		}
+	}
+
+	// Feed messages from the page to the program, and stop it once the page
+	// goes away.
+	go func() {
+		defer cancel()
+		for {
+			typ, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			if typ == websocket.MessageBinary {
+				prog.Input(data)
+				continue
+			}
+			var msg serverMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				continue
+			}
+			handleServerMessage(prog, msg)
+		}
+	}()
+
+	// Send output as it is written, and whatever is left once Run returns.
+	exited := make(chan struct{})
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for {
+			select {
+			case <-ready:
+			case <-exited:
+			}
+			if data := prog.output.Drain(); len(data) > 0 {
+				if err := conn.Write(ctx, websocket.MessageBinary, data); err != nil {
+					cancel()
+					return
+				}
+			}
+			select {
+			case <-exited:
+				return
+			default:
+			}
+		}
+	}()
+
+	model, err := prog.Run()
+	close(exited)
+	<-written
+
+	exit := serverMessage{Type: "exit", Reason: exitReason(model, err)}
+	if err != nil {
+		msg := err.Error()
+		exit.Error = &msg
+	}
+	if s, ok := model.(ExitSummarizer); ok {
+		exit.Model = s.ExitSummary()
+	}
+	if data, err := json.Marshal(exit); err == nil {
+		_ = conn.Write(ctx, websocket.MessageText, data)
+	}
+	conn.Close(websocket.StatusNormalClosure, string(exit.Reason))
+}
+
+// handleServerMessage applies a control message from the page to prog.
+func handleServerMessage(prog *Program, msg serverMessage) {
+	switch msg.Type {
+	case "write":
+		prog.Input([]byte(msg.Data))
+	case "paste":
+		prog.Paste(msg.Data)
+	case "focus":
+		prog.Focus(msg.Focused)
+	case "link":
+		prog.Link(msg.URL)
+	case "resize":
+		prog.Resize(msg.Cols, msg.Rows)
+	case "mouse":
+		prog.Mouse(tea.MouseMsg{
+			Action: msg.Action,
+			Button: msg.Button,
+			X:      msg.X,
+			Y:      msg.Y,
+			Alt:    msg.Alt,
+			Ctrl:   msg.Ctrl,
+			Shift:  msg.Shift,
+		})
+	case "key":
+		prog.Key(KeyEvent{
+			Key:    msg.Key,
+			Code:   msg.Code,
+			Ctrl:   msg.Ctrl,
+			Alt:    msg.Alt,
+			Shift:  msg.Shift,
+			Meta:   msg.Meta,
+			Repeat: msg.Repeat,
+		})
+	case "quit":
+		go prog.Quit()
+	case "kill":
+		go prog.Kill()
+	}
+}
@@ -0,0 +1,224 @@
+//go:build !js
+// +build !js
+
+package bubbweb_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/tmc/bubbweb"
+)
+
+// keyModel shows the keys it has received.
+type keyModel struct{ keys string }
+
+func (m keyModel) Init() tea.Cmd { return nil }
+
+func (m keyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		m.keys += "<" + msg.String() + ">"
+	}
+	return m, nil
+}
+
+func (m keyModel) View() string { return "keys: " + m.keys }
+
+// sessionModel shows what is typed into it and the terminal size, and quits
+// on enter.
+type sessionModel struct {
+	typed         string
+	width, height int
+}
+
+func (m sessionModel) Init() tea.Cmd { return nil }
+
+func (m sessionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyEnter {
+			return m, tea.Quit
+		}
+		m.typed += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m sessionModel) View() string {
+	return fmt.Sprintf("typed: %s\nsize %dx%d", m.typed, m.width, m.height)
+}
+
+func (m sessionModel) ExitSummary() any {
+	return map[string]any{"typed": m.typed}
+}
+
+// session is a connection to a program served by Handler.
+type session struct {
+	t      *testing.T
+	ctx    context.Context
+	conn   *websocket.Conn
+	output strings.Builder
+}
+
+// dial serves the models newModel returns with Handler and connects to it.
+func dial(t *testing.T, newModel func(bubbweb.Session) tea.Model) *session {
+	t.Helper()
+	srv := httptest.NewServer(bubbweb.Handler(newModel))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+	return &session{t: t, ctx: ctx, conn: conn}
+}
+
+// send sends a JSON message to the program.
+func (s *session) send(msg map[string]any) {
+	s.t.Helper()
+	if err := wsjson.Write(s.ctx, s.conn, msg); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// read reads the next message, adding output to s.output, and returns the
+// JSON message it was, or nil for output.
+func (s *session) read() map[string]any {
+	s.t.Helper()
+	typ, data, err := s.conn.Read(s.ctx)
+	if err != nil {
+		s.t.Fatalf("reading: %v; output so far:\n%q", err, s.output.String())
+	}
+	if typ == websocket.MessageBinary {
+		s.output.Write(data)
+		return nil
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil {
+		s.t.Fatalf("decoding %q: %v", data, err)
+	}
+	return msg
+}
+
+// waitForOutput reads until the program's output contains want.
+func (s *session) waitForOutput(want string) {
+	s.t.Helper()
+	for !strings.Contains(s.output.String(), want) {
+		s.read()
+	}
+}
+
+// waitForExit reads until the "exit" message and returns it.
+func (s *session) waitForExit() map[string]any {
+	s.t.Helper()
+	for {
+		if msg := s.read(); msg["type"] == "exit" {
+			return msg
+		}
+	}
+}
+
+func TestHandlerInput(t *testing.T) {
+	s := dial(t, func(bubbweb.Session) tea.Model { return sessionModel{} })
+	s.waitForOutput("typed:")
+
+	if err := s.conn.Write(s.ctx, websocket.MessageBinary, []byte("ab")); err != nil {
+		t.Fatal(err)
+	}
+	s.waitForOutput("typed: ab")
+	s.send(map[string]any{"type": "write", "data": "c"})
+	s.waitForOutput("typed: abc")
+}
+
+func TestHandlerResize(t *testing.T) {
+	s := dial(t, func(bubbweb.Session) tea.Model { return sessionModel{} })
+	s.waitForOutput("typed:")
+
+	s.send(map[string]any{"type": "resize", "cols": 100, "rows": 30})
+	s.waitForOutput("size 100x30")
+
+	// Sizes beyond what a page could show are clamped.
+	s.send(map[string]any{"type": "resize", "cols": 100000, "rows": 100000})
+	s.waitForOutput("size 500x200")
+}
+
+func TestHandlerExit(t *testing.T) {
+	s := dial(t, func(bubbweb.Session) tea.Model { return sessionModel{} })
+	s.waitForOutput("typed:")
+
+	s.send(map[string]any{"type": "write", "data": "hi\r"})
+	exit := s.waitForExit()
+	if exit["reason"] != "quit" {
+		t.Errorf("reason = %v, want quit", exit["reason"])
+	}
+	if err, ok := exit["error"]; ok && err != nil {
+		t.Errorf("error = %v, want none", err)
+	}
+	if model, _ := exit["model"].(map[string]any); model["typed"] != "hi" {
+		t.Errorf("model = %v, want the summary with typed hi", exit["model"])
+	}
+	if !strings.Contains(s.output.String(), "typed: hi") {
+		t.Errorf("output %q does not end with the final view", s.output.String())
+	}
+
+	// The server closes the connection once it has reported the exit.
+	_, _, err := s.conn.Read(s.ctx)
+	if status := websocket.CloseStatus(err); status != websocket.StatusNormalClosure {
+		t.Errorf("connection closed with %v, want a normal closure", err)
+	}
+}
+
+func TestHandlerKill(t *testing.T) {
+	s := dial(t, func(bubbweb.Session) tea.Model { return sessionModel{} })
+	s.waitForOutput("typed:")
+
+	s.send(map[string]any{"type": "write", "data": "x"})
+	s.waitForOutput("typed: x")
+	s.send(map[string]any{"type": "kill"})
+	exit := s.waitForExit()
+	if exit["reason"] != "killed" {
+		t.Errorf("reason = %v, want killed", exit["reason"])
+	}
+	if msg, _ := exit["error"].(string); !strings.Contains(msg, "killed") {
+		t.Errorf("error = %v, want the killed error", exit["error"])
+	}
+	if model, _ := exit["model"].(map[string]any); model["typed"] != "x" {
+		t.Errorf("model = %v, want the summary with typed x", exit["model"])
+	}
+}
+
+func TestHandlerKey(t *testing.T) {
+	s := dial(t, func(bubbweb.Session) tea.Model { return keyModel{} })
+	for _, key := range []map[string]any{
+		{"type": "key", "key": "с", "code": "KeyC", "ctrl": true},
+		{"type": "key", "key": "ArrowUp", "shift": true},
+		{"type": "key", "key": "v", "code": "KeyV", "meta": true},
+		{"type": "key", "key": "x", "code": "KeyX", "alt": true},
+	} {
+		s.send(key)
+	}
+	s.waitForOutput("<alt+x>")
+	if got, want := s.output.String(), "<ctrl+c><shift+up><alt+x>"; !strings.Contains(got, want) {
+		t.Errorf("output %q does not contain %q", got, want)
+	}
+}
+
+func TestHandlerMouse(t *testing.T) {
+	s := dial(t, func(bubbweb.Session) tea.Model { return keyModel{} })
+
+	// Enabling cell motion reporting writes CSI ? 1002 h.
+	s.waitForOutput("\x1b[?1002h")
+}
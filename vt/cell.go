@@ -0,0 +1,104 @@
+package vt
+
+// ColorType distinguishes the kinds of Color.
+type ColorType uint8
+
+const (
+	// ColorDefault is the terminal's default foreground or background.
+	ColorDefault ColorType = iota
+	// ColorIndexed is one of the 256 palette colors; 0-15 are the ANSI colors.
+	ColorIndexed
+	// ColorRGB is a 24-bit color.
+	ColorRGB
+)
+
+// Color is a foreground or background color. The zero value is the default
+// color.
+type Color struct {
+	Type    ColorType
+	Index   uint8 // palette index, for ColorIndexed
+	R, G, B uint8 // components, for ColorRGB
+}
+
+// IndexedColor returns the palette color i.
+func IndexedColor(i uint8) Color {
+	return Color{Type: ColorIndexed, Index: i}
+}
+
+// RGBColor returns the 24-bit color r, g, b.
+func RGBColor(r, g, b uint8) Color {
+	return Color{Type: ColorRGB, R: r, G: g, B: b}
+}
+
+// Attr is a set of text attributes selected by SGR sequences.
+type Attr uint16
+
+const (
+	Bold Attr = 1 << iota
+	Faint
+	Italic
+	Underline
+	Blink
+	Reverse
+	Conceal
+	Strikethrough
+)
+
+// attrNames lists the attributes in the order of their bits.
+var attrNames = []string{
+	"bold", "faint", "italic", "underline", "blink", "reverse", "conceal", "strikethrough",
+}
+
+// String returns the attribute names separated by commas.
+func (a Attr) String() string {
+	var s string
+	for i, name := range attrNames {
+		if a&(1<<i) != 0 {
+			if s != "" {
+				s += ","
+			}
+			s += name
+		}
+	}
+	return s
+}
+
+// Style is the appearance of a cell.
+type Style struct {
+	Fg, Bg Color
+	Attrs  Attr
+}
+
+// Cell is one column of the screen.
+type Cell struct {
+	// Content is the character in the cell, including any combining marks
+	// following it. It is empty for a blank cell and for the second column
+	// of a wide character.
+	Content string
+
+	// Width is the number of columns the character occupies: 1, or 2 for a
+	// wide character, or 0 for the column a wide character spills into.
+	Width int
+
+	Style Style
+
+	// Link is the URI of the OSC 8 hyperlink the cell is part of, if any.
+	Link string
+}
+
+// blank returns an empty cell with the background of style, as erasing
+// operations leave behind.
+func blank(style Style) Cell {
+	return Cell{Width: 1, Style: Style{Bg: style.Bg}}
+}
+
+// Text returns the character in the cell, or a space if it is blank.
+func (c Cell) Text() string {
+	if c.Content == "" {
+		if c.Width == 0 {
+			return ""
+		}
+		return " "
+	}
+	return c.Content
+}
@@ -0,0 +1,264 @@
+package vt
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Palette maps the screen's colors to the ones an export is drawn with.
+// Colors are CSS hex colors such as "#121212".
+type Palette struct {
+	Foreground string
+	Background string
+
+	// ANSI holds the 16 ANSI colors, normal then bright. The remaining
+	// palette colors are the standard 6x6x6 cube and grayscale ramp.
+	ANSI [16]string
+}
+
+// xtermANSI is the ANSI palette of xterm.js, which the page uses.
+var xtermANSI = [16]string{
+	"#2e3436", "#cc0000", "#4e9a06", "#c4a000", "#3465a4", "#75507b", "#06989a", "#d3d7cf",
+	"#555753", "#ef2929", "#8ae234", "#fce94f", "#729fcf", "#ad7fa8", "#34e2e2", "#eeeeec",
+}
+
+// DarkPalette and LightPalette match the page's dark and light themes.
+var (
+	DarkPalette  = Palette{Foreground: "#f8f8f8", Background: "#121212", ANSI: xtermANSI}
+	LightPalette = Palette{Foreground: "#2c3e50", Background: "#f8f8f8", ANSI: xtermANSI}
+)
+
+// color returns the CSS color for c, or def for the default color.
+func (p Palette) color(c Color, def string) string {
+	switch c.Type {
+	case ColorRGB:
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	case ColorIndexed:
+		i := int(c.Index)
+		switch {
+		case i < 16:
+			return p.ANSI[i]
+		case i < 232:
+			i -= 16
+			level := func(v int) int {
+				if v == 0 {
+					return 0
+				}
+				return 55 + v*40
+			}
+			return fmt.Sprintf("#%02x%02x%02x", level(i/36), level(i/6%6), level(i%6))
+		default:
+			v := 8 + (i-232)*10
+			return fmt.Sprintf("#%02x%02x%02x", v, v, v)
+		}
+	}
+	return def
+}
+
+// colors returns the foreground and background CSS colors of st, with
+// reverse video and concealment applied.
+func (p Palette) colors(st Style) (fg, bg string) {
+	fg = p.color(st.Fg, p.Foreground)
+	bg = p.color(st.Bg, p.Background)
+	if st.Attrs&Reverse != 0 {
+		fg, bg = bg, fg
+	}
+	if st.Attrs&Conceal != 0 {
+		fg = bg
+	}
+	return fg, bg
+}
+
+// run is a stretch of a row in one style.
+type run struct {
+	x, width int // first column and number of columns
+	text     string
+	style    Style
+	link     string
+}
+
+// runs splits row y into runs of cells with the same style and link.
+// Trailing blanks in the default style are dropped. The caller must hold s.mu.
+func (s *Screen) runs(y int) []run {
+	line := s.buf.lines[y]
+	end := len(line)
+	for end > 0 && line[end-1].Content == "" && line[end-1].Style == (Style{}) {
+		end--
+	}
+
+	var runs []run
+	var b strings.Builder
+	for x := 0; x < end; x++ {
+		c := line[x]
+		if c.Width == 0 {
+			if len(runs) > 0 {
+				runs[len(runs)-1].width++
+			}
+			continue
+		}
+		if len(runs) == 0 || runs[len(runs)-1].style != c.Style || runs[len(runs)-1].link != c.Link {
+			if len(runs) > 0 {
+				runs[len(runs)-1].text = b.String()
+				b.Reset()
+			}
+			runs = append(runs, run{x: x, style: c.Style, link: c.Link})
+		}
+		runs[len(runs)-1].width++
+		b.WriteString(c.Text())
+	}
+	if len(runs) > 0 {
+		runs[len(runs)-1].text = b.String()
+	}
+	return runs
+}
+
+// cssStyle returns the inline CSS for a run in st.
+func (p Palette) cssStyle(st Style) string {
+	var css []string
+	fg, bg := p.colors(st)
+	if fg != p.Foreground {
+		css = append(css, "color:"+fg)
+	}
+	if bg != p.Background {
+		css = append(css, "background-color:"+bg)
+	}
+	if st.Attrs&Bold != 0 {
+		css = append(css, "font-weight:bold")
+	}
+	if st.Attrs&Faint != 0 {
+		css = append(css, "opacity:0.5")
+	}
+	if st.Attrs&Italic != 0 {
+		css = append(css, "font-style:italic")
+	}
+	var decorations []string
+	if st.Attrs&Underline != 0 {
+		decorations = append(decorations, "underline")
+	}
+	if st.Attrs&Strikethrough != 0 {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		css = append(css, "text-decoration:"+strings.Join(decorations, " "))
+	}
+	return strings.Join(css, ";")
+}
+
+// webLink reports whether uri is safe to link to from an export: an http,
+// https or mailto URI.
+func webLink(uri string) bool {
+	scheme, _, ok := strings.Cut(strings.ToLower(uri), ":")
+	return ok && (scheme == "http" || scheme == "https" || scheme == "mailto")
+}
+
+// HTML renders the screen as a self-contained HTML document holding a <pre>
+// element, drawn with the colors of p. Hyperlinks to web pages and mail
+// addresses are kept as links.
+func (s *Screen) HTML(p Palette) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b bytes.Buffer
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<style>\n")
+	fmt.Fprintf(&b, "body { margin: 0; background-color: %s; }\n", p.Background)
+	fmt.Fprintf(&b, "pre { margin: 0; padding: 1em; color: %s; background-color: %s; "+
+		"font-family: ui-monospace, Menlo, Consolas, monospace; font-size: 14px; line-height: 1.2; }\n",
+		p.Foreground, p.Background)
+	b.WriteString("</style>\n</head>\n<body>\n<pre>")
+	for y := 0; y < s.height; y++ {
+		if y > 0 {
+			b.WriteByte('\n')
+		}
+		for _, r := range s.runs(y) {
+			text := html.EscapeString(r.text)
+			if css := p.cssStyle(r.style); css != "" {
+				text = fmt.Sprintf("<span style=\"%s\">%s</span>", css, text)
+			}
+			if webLink(r.link) {
+				text = fmt.Sprintf("<a href=\"%s\" style=\"color:inherit\">%s</a>", html.EscapeString(r.link), text)
+			}
+			b.WriteString(text)
+		}
+	}
+	b.WriteString("</pre>\n</body>\n</html>\n")
+	return b.Bytes()
+}
+
+// SVG cell metrics, for a 14px monospace font.
+const (
+	svgFontSize   = 14
+	svgCellWidth  = 8.4
+	svgCellHeight = 17
+	svgPadding    = 14
+)
+
+// SVG renders the screen as a self-contained SVG image, drawn with the
+// colors of p. Each run of text is stretched to its columns, so the grid
+// lines up whatever monospace font the viewer substitutes.
+func (s *Screen) SVG(p Palette) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	width := float64(s.width)*svgCellWidth + 2*svgPadding
+	height := float64(s.height)*svgCellHeight + 2*svgPadding
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %[1]s %[2]s\">\n",
+		num(width), num(height))
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", p.Background)
+	fmt.Fprintf(&b, "<g font-family=\"ui-monospace, Menlo, Consolas, monospace\" font-size=\"%d\" fill=\"%s\" xml:space=\"preserve\">\n",
+		svgFontSize, p.Foreground)
+
+	for y := 0; y < s.height; y++ {
+		top := svgPadding + float64(y)*svgCellHeight
+		for _, r := range s.runs(y) {
+			left := svgPadding + float64(r.x)*svgCellWidth
+			span := float64(r.width) * svgCellWidth
+			fg, bg := p.colors(r.style)
+			if bg != p.Background {
+				fmt.Fprintf(&b, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%d\" fill=\"%s\"/>\n",
+					num(left), num(top), num(span), svgCellHeight, bg)
+			}
+			if strings.TrimSpace(r.text) == "" {
+				continue
+			}
+
+			attrs := fmt.Sprintf(" x=\"%s\" y=\"%s\" textLength=\"%s\" lengthAdjust=\"spacingAndGlyphs\"",
+				num(left), num(top+svgCellHeight*0.8), num(span))
+			if fg != p.Foreground {
+				attrs += fmt.Sprintf(" fill=\"%s\"", fg)
+			}
+			if r.style.Attrs&Bold != 0 {
+				attrs += " font-weight=\"bold\""
+			}
+			if r.style.Attrs&Faint != 0 {
+				attrs += " opacity=\"0.5\""
+			}
+			if r.style.Attrs&Italic != 0 {
+				attrs += " font-style=\"italic\""
+			}
+			var decorations []string
+			if r.style.Attrs&Underline != 0 {
+				decorations = append(decorations, "underline")
+			}
+			if r.style.Attrs&Strikethrough != 0 {
+				decorations = append(decorations, "line-through")
+			}
+			if len(decorations) > 0 {
+				attrs += fmt.Sprintf(" text-decoration=\"%s\"", strings.Join(decorations, " "))
+			}
+			fmt.Fprintf(&b, "<text%s>%s</text>\n", attrs, html.EscapeString(r.text))
+		}
+	}
+	b.WriteString("</g>\n</svg>\n")
+	return b.Bytes()
+}
+
+// num formats an SVG coordinate to two decimal places at most.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
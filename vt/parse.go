@@ -0,0 +1,499 @@
+package vt
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// parserState is the state of the escape sequence parser, after the
+// state machine described at https://vt100.net/emu/dec_ansi_parser.
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateEscapeIntermediate
+	stateCSI
+	stateOSC
+	stateOSCEscape // ESC seen inside an OSC, possibly starting ST
+	stateString    // DCS, SOS, PM or APC, ignored until ST
+	stateStringEscape
+)
+
+// parser holds a partly received escape sequence.
+type parser struct {
+	state        parserState
+	private      byte // CSI private marker, such as '?'
+	intermediate []byte
+	params       []byte
+	osc          []byte
+}
+
+// maxSequence bounds the bytes collected for a single sequence, so a stream
+// that never terminates one cannot grow the parser without limit.
+const maxSequence = 1 << 16
+
+// Write interprets p as terminal output. It always consumes all of p.
+// Sequences and UTF-8 characters may be split across writes.
+func (s *Screen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	var hooks []func()
+	n := len(p)
+	if len(s.partial) > 0 {
+		p = append(s.partial, p...)
+		s.partial = nil
+	}
+	for len(p) > 0 {
+		b := p[0]
+		if b < utf8.RuneSelf || s.parser.state != stateGround {
+			hooks = s.advance(b, hooks)
+			p = p[1:]
+			continue
+		}
+		if !utf8.FullRune(p) {
+			s.partial = append([]byte(nil), p...)
+			break
+		}
+		r, size := utf8.DecodeRune(p)
+		s.print(r, runewidth.RuneWidth(r))
+		p = p[size:]
+	}
+	s.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	return n, nil
+}
+
+// advance feeds one byte to the parser, appending to hooks any callbacks
+// to run once the screen is unlocked.
+func (s *Screen) advance(b byte, hooks []func()) []func() {
+	ps := &s.parser
+
+	// Controls that apply in any state.
+	switch b {
+	case 0x18, 0x1a: // CAN, SUB abort a sequence
+		ps.state = stateGround
+		return hooks
+	case 0x1b:
+		switch ps.state {
+		case stateOSC:
+			ps.state = stateOSCEscape
+		case stateString:
+			ps.state = stateStringEscape
+		default:
+			*ps = parser{state: stateEscape, params: ps.params[:0], intermediate: ps.intermediate[:0]}
+		}
+		return hooks
+	}
+
+	switch ps.state {
+	case stateGround:
+		if b < 0x20 || b == 0x7f {
+			return s.control(b, hooks)
+		}
+		s.print(rune(b), 1)
+
+	case stateEscape, stateEscapeIntermediate:
+		switch {
+		case b < 0x20:
+			return s.control(b, hooks)
+		case b < 0x30:
+			ps.intermediate = append(ps.intermediate, b)
+			ps.state = stateEscapeIntermediate
+		case ps.state == stateEscape && b == '[':
+			ps.state = stateCSI
+			ps.private = 0
+		case ps.state == stateEscape && b == ']':
+			ps.state = stateOSC
+			ps.osc = ps.osc[:0]
+		case ps.state == stateEscape && (b == 'P' || b == 'X' || b == '^' || b == '_'):
+			ps.state = stateString
+		default:
+			ps.state = stateGround
+			s.escape(b)
+		}
+
+	case stateCSI:
+		switch {
+		case b < 0x20:
+			return s.control(b, hooks)
+		case b >= '<' && b <= '?' && len(ps.params) == 0 && ps.private == 0:
+			ps.private = b
+		case b >= '0' && b <= ';':
+			if len(ps.params) < maxSequence {
+				ps.params = append(ps.params, b)
+			}
+		case b >= 0x20 && b < 0x30:
+			ps.intermediate = append(ps.intermediate, b)
+		case b >= 0x40 && b < 0x7f:
+			ps.state = stateGround
+			return s.csi(b, hooks)
+		default:
+			// Malformed; ignore the rest of the sequence.
+		}
+
+	case stateOSC:
+		if b == 0x07 {
+			ps.state = stateGround
+			return s.oscDone(hooks)
+		}
+		if len(ps.osc) < maxSequence {
+			ps.osc = append(ps.osc, b)
+		}
+
+	case stateOSCEscape:
+		ps.state = stateGround
+		hooks = s.oscDone(hooks)
+		if b != '\\' {
+			return s.advance(b, hooks)
+		}
+
+	case stateString:
+		// Ignore until ST.
+
+	case stateStringEscape:
+		ps.state = stateString
+		if b == '\\' {
+			ps.state = stateGround
+		}
+	}
+	return hooks
+}
+
+// control executes a C0 control character.
+func (s *Screen) control(b byte, hooks []func()) []func() {
+	switch b {
+	case 0x07: // BEL
+		s.bells++
+		if fn := s.onBell; fn != nil {
+			hooks = append(hooks, fn)
+		}
+	case 0x08: // BS
+		if s.cur.x > 0 {
+			s.cur.x--
+		}
+		s.cur.wrapNext = false
+	case 0x09: // HT, with a tab stop every eight columns
+		s.cur.x = min((s.cur.x/8+1)*8, s.width-1)
+		s.cur.wrapNext = false
+	case 0x0a, 0x0b, 0x0c: // LF, VT, FF
+		s.lineFeed()
+	case 0x0d: // CR
+		s.cur.x = 0
+		s.cur.wrapNext = false
+	}
+	return hooks
+}
+
+// escape executes the escape sequence ending in final.
+func (s *Screen) escape(final byte) {
+	if len(s.parser.intermediate) > 0 {
+		// Character set designations such as ESC ( B; only ASCII is used.
+		return
+	}
+	switch final {
+	case '7': // DECSC
+		s.saveCursor()
+	case '8': // DECRC
+		s.restoreCursor()
+	case 'D': // IND
+		s.lineFeed()
+	case 'E': // NEL
+		s.cur.x = 0
+		s.lineFeed()
+	case 'M': // RI
+		s.reverseIndex()
+	case 'c': // RIS
+		s.reset()
+	}
+}
+
+// csiParams parses the CSI parameters. Sub-parameters separated by colons are
+// kept together, so each parameter is a list of at least one value, where -1
+// marks a value that was omitted.
+func csiParams(raw []byte) [][]int {
+	if len(raw) == 0 {
+		return nil
+	}
+	var params [][]int
+	for _, field := range strings.Split(string(raw), ";") {
+		var param []int
+		for _, sub := range strings.Split(field, ":") {
+			v, err := strconv.Atoi(sub)
+			if err != nil || v < 0 {
+				v = -1
+			}
+			param = append(param, min(v, 1<<16))
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// param returns the value of parameter i, or def if it was omitted or zero.
+func param(params [][]int, i, def int) int {
+	if i >= len(params) || params[i][0] <= 0 {
+		return def
+	}
+	return params[i][0]
+}
+
+// csi executes the control sequence ending in final.
+func (s *Screen) csi(final byte, hooks []func()) []func() {
+	ps := &s.parser
+	params := csiParams(ps.params)
+
+	if ps.private == '?' {
+		switch final {
+		case 'h', 'l':
+			for _, p := range params {
+				hooks = s.setMode(p[0], final == 'h', hooks)
+			}
+		}
+		return hooks
+	}
+	if ps.private != 0 || len(ps.intermediate) > 0 {
+		// Queries and settings such as cursor style (CSI q with a space)
+		// that do not change the screen.
+		return hooks
+	}
+
+	switch final {
+	case '@': // ICH
+		s.insertCells(param(params, 0, 1))
+	case 'A': // CUU
+		s.moveVertically(-param(params, 0, 1))
+	case 'B', 'e': // CUD, VPR
+		s.moveVertically(param(params, 0, 1))
+	case 'C', 'a': // CUF, HPR
+		s.moveHorizontally(param(params, 0, 1))
+	case 'D': // CUB
+		s.moveHorizontally(-param(params, 0, 1))
+	case 'E': // CNL
+		s.moveVertically(param(params, 0, 1))
+		s.cur.x = 0
+	case 'F': // CPL
+		s.moveVertically(-param(params, 0, 1))
+		s.cur.x = 0
+	case 'G', '`': // CHA, HPA
+		s.cur.x = min(param(params, 0, 1)-1, s.width-1)
+		s.cur.wrapNext = false
+	case 'H', 'f': // CUP, HVP
+		s.moveTo(param(params, 1, 1)-1, param(params, 0, 1)-1)
+	case 'd': // VPA
+		s.moveTo(s.cur.x, param(params, 0, 1)-1)
+	case 'J': // ED
+		switch param(params, 0, 0) {
+		case 0:
+			s.eraseCells(s.cur.y, s.cur.x, s.width)
+			s.eraseLines(s.cur.y+1, s.height)
+		case 1:
+			s.eraseLines(0, s.cur.y)
+			s.eraseCells(s.cur.y, 0, s.cur.x+1)
+		case 2, 3:
+			s.eraseLines(0, s.height)
+		}
+		s.cur.wrapNext = false
+	case 'K': // EL
+		switch param(params, 0, 0) {
+		case 0:
+			s.eraseCells(s.cur.y, s.cur.x, s.width)
+		case 1:
+			s.eraseCells(s.cur.y, 0, s.cur.x+1)
+		case 2:
+			s.eraseCells(s.cur.y, 0, s.width)
+		}
+		s.cur.wrapNext = false
+	case 'L': // IL
+		s.insertLinesAt(s.cur.y, param(params, 0, 1))
+		s.cur.x = 0
+	case 'M': // DL
+		s.deleteLinesAt(s.cur.y, param(params, 0, 1))
+		s.cur.x = 0
+	case 'P': // DCH
+		s.deleteCells(param(params, 0, 1))
+	case 'S': // SU
+		s.scrollUp(param(params, 0, 1))
+	case 'T': // SD
+		s.scrollDown(param(params, 0, 1))
+	case 'X': // ECH
+		s.eraseCells(s.cur.y, s.cur.x, s.cur.x+param(params, 0, 1))
+	case 'm': // SGR
+		s.sgr(params)
+	case 'r': // DECSTBM
+		s.setScrollRegion(param(params, 0, 1)-1, param(params, 1, s.height)-1)
+	case 's': // SCOSC
+		s.saveCursor()
+	case 'u': // SCORC
+		s.restoreCursor()
+	}
+	return hooks
+}
+
+// setMode sets or resets the DEC private mode n.
+func (s *Screen) setMode(n int, set bool, hooks []func()) []func() {
+	switch n {
+	case ModeOrigin:
+		s.cur.origin = set
+		s.moveTo(0, 0)
+	case ModeAltScreen, modeAltScreenLegacy:
+		s.setAltScreen(set, n == ModeAltScreen && set)
+	case ModeSaveCursor:
+		if set {
+			s.saveCursor()
+		} else {
+			s.restoreCursor()
+		}
+	case ModeAltScreenSave:
+		if set {
+			s.main.saved = s.cur
+			s.setAltScreen(true, true)
+		} else {
+			s.setAltScreen(false, false)
+			s.restoreCursor()
+		}
+	}
+	s.modes[n] = set
+	if fn := s.onMode; fn != nil {
+		hooks = append(hooks, func() { fn(n, set) })
+	}
+	return hooks
+}
+
+// sgr applies Select Graphic Rendition parameters to the cursor style.
+func (s *Screen) sgr(params [][]int) {
+	st := &s.cur.style
+	if len(params) == 0 {
+		*st = Style{}
+		return
+	}
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		switch v := max(p[0], 0); {
+		case v == 0:
+			*st = Style{}
+		case v == 1:
+			st.Attrs |= Bold
+		case v == 2:
+			st.Attrs |= Faint
+		case v == 3:
+			st.Attrs |= Italic
+		case v == 4:
+			// 4:0 turns underline off; other styles are all underline.
+			if len(p) > 1 && p[1] == 0 {
+				st.Attrs &^= Underline
+			} else {
+				st.Attrs |= Underline
+			}
+		case v == 5 || v == 6:
+			st.Attrs |= Blink
+		case v == 7:
+			st.Attrs |= Reverse
+		case v == 8:
+			st.Attrs |= Conceal
+		case v == 9:
+			st.Attrs |= Strikethrough
+		case v == 21:
+			st.Attrs |= Underline
+		case v == 22:
+			st.Attrs &^= Bold | Faint
+		case v == 23:
+			st.Attrs &^= Italic
+		case v == 24:
+			st.Attrs &^= Underline
+		case v == 25:
+			st.Attrs &^= Blink
+		case v == 27:
+			st.Attrs &^= Reverse
+		case v == 28:
+			st.Attrs &^= Conceal
+		case v == 29:
+			st.Attrs &^= Strikethrough
+		case v >= 30 && v <= 37:
+			st.Fg = IndexedColor(uint8(v - 30))
+		case v == 38:
+			st.Fg, i = extendedColor(params, i)
+		case v == 39:
+			st.Fg = Color{}
+		case v >= 40 && v <= 47:
+			st.Bg = IndexedColor(uint8(v - 40))
+		case v == 48:
+			st.Bg, i = extendedColor(params, i)
+		case v == 49:
+			st.Bg = Color{}
+		case v == 58:
+			// Underline color is not tracked, but its arguments must be skipped.
+			_, i = extendedColor(params, i)
+		case v >= 90 && v <= 97:
+			st.Fg = IndexedColor(uint8(v - 90 + 8))
+		case v >= 100 && v <= 107:
+			st.Bg = IndexedColor(uint8(v - 100 + 8))
+		}
+	}
+}
+
+// extendedColor parses the color selected by the 38, 48 or 58 parameter at
+// index i, in either the 38;5;n form or the 38:5:n form, and returns it with
+// the index of the last parameter it used.
+func extendedColor(params [][]int, i int) (Color, int) {
+	args := params[i][1:]
+	next := i
+	if len(args) == 0 {
+		// Semicolon form: the arguments are the following parameters.
+		for _, p := range params[i+1:] {
+			args = append(args, p[0])
+		}
+	}
+	byteAt := func(j int) uint8 {
+		if j < len(args) && args[j] > 0 {
+			return uint8(min(args[j], 255))
+		}
+		return 0
+	}
+	if len(args) == 0 {
+		return Color{}, i
+	}
+	switch args[0] {
+	case 5:
+		if len(params[i]) == 1 {
+			next = i + 2
+		}
+		return IndexedColor(byteAt(1)), min(next, len(params)-1)
+	case 2:
+		// The colon form may include a color space ID before the components.
+		off := 1
+		if len(params[i]) > 1 && len(args) >= 5 {
+			off = 2
+		}
+		if len(params[i]) == 1 {
+			next = i + 4
+		}
+		return RGBColor(byteAt(off), byteAt(off+1), byteAt(off+2)), min(next, len(params)-1)
+	}
+	return Color{}, i
+}
+
+// oscDone handles a complete operating system command.
+func (s *Screen) oscDone(hooks []func()) []func() {
+	cmdText, data, _ := strings.Cut(string(s.parser.osc), ";")
+	cmd, err := strconv.Atoi(cmdText)
+	if err != nil {
+		return hooks
+	}
+	switch cmd {
+	case 0, 2:
+		s.title = data
+	case 8:
+		// OSC 8 ; params ; URI starts a hyperlink, and an empty URI ends it.
+		_, s.link, _ = strings.Cut(data, ";")
+	}
+	if fn := s.onOSC; fn != nil {
+		hooks = append(hooks, func() { fn(cmd, data) })
+	}
+	return hooks
+}
@@ -0,0 +1,565 @@
+// Package vt emulates enough of an xterm-compatible terminal to interpret the
+// output of BubbleTea programs: everything the bubbletea renderer and lipgloss
+// emit, written to a Screen, becomes a grid of cells that can be inspected.
+//
+//	scr := vt.New(80, 24)
+//	scr.Write(prog.Output().Drain())
+//	fmt.Println(scr.String())
+//
+// It handles printable text including wide characters and combining marks,
+// cursor movement, erasing, insertion and deletion, scroll regions, SGR
+// colors and attributes, the alternate screen, and tracks the DEC private
+// modes a program sets, such as mouse reporting and bracketed paste.
+// Operating system commands are recorded or passed to a hook, and OSC 8
+// hyperlinks are kept with the cells they cover.
+//
+// Queries such as device status reports are ignored, since there is nothing
+// to answer them.
+package vt
+
+import (
+	"strings"
+	"sync"
+)
+
+// Well-known DEC private modes, as set with CSI ? n h and reset with CSI ? n l.
+const (
+	ModeCursorKeys      = 1
+	ModeOrigin          = 6
+	ModeAutoWrap        = 7
+	ModeCursorVisible   = 25
+	ModeMouseX10        = 9
+	ModeMouseNormal     = 1000
+	ModeMouseButton     = 1002
+	ModeMouseAny        = 1003
+	ModeFocusReporting  = 1004
+	ModeMouseSGR        = 1006
+	ModeAltScreen       = 1047
+	ModeSaveCursor      = 1048
+	ModeAltScreenSave   = 1049
+	ModeBracketedPaste  = 2004
+	ModeSynchronized    = 2026
+	modeAltScreenLegacy = 47
+)
+
+// Cursor is the position and visibility of the cursor. X and Y count from
+// zero at the top left.
+type Cursor struct {
+	X, Y    int
+	Visible bool
+}
+
+// cursor is the full cursor state, as saved by DECSC.
+type cursor struct {
+	x, y  int
+	style Style
+
+	// wrapNext is set once a character is printed in the last column; the
+	// next one wraps to a new line first.
+	wrapNext bool
+	origin   bool
+}
+
+// buffer is a grid of lines, one for the main and one for the alternate
+// screen.
+type buffer struct {
+	lines [][]Cell
+	saved cursor
+}
+
+func newBuffer(width, height int) *buffer {
+	b := &buffer{lines: make([][]Cell, height)}
+	for y := range b.lines {
+		b.lines[y] = blankLine(width, Style{})
+	}
+	return b
+}
+
+func blankLine(width int, style Style) []Cell {
+	line := make([]Cell, width)
+	for x := range line {
+		line[x] = blank(style)
+	}
+	return line
+}
+
+// Screen is an emulated terminal screen. It is an io.Writer: everything
+// written to it is interpreted as terminal output. It is safe for concurrent
+// use.
+type Screen struct {
+	mu sync.Mutex
+
+	width, height int
+	main, alt     *buffer
+	buf           *buffer // main or alt
+	cur           cursor
+
+	// Scroll region, inclusive.
+	top, bottom int
+
+	modes   map[int]bool // DEC private modes
+	link    string       // URI of the OSC 8 hyperlink being printed
+	title   string
+	bells   int
+	onOSC   func(cmd int, data string)
+	onMode  func(mode int, set bool)
+	onBell  func()
+	parser  parser
+	partial []byte // incomplete UTF-8 sequence from the last write
+}
+
+// New returns a blank screen of width columns by height rows.
+func New(width, height int) *Screen {
+	width, height = max(width, 1), max(height, 1)
+	s := &Screen{width: width, height: height}
+	s.reset()
+	return s
+}
+
+// reset returns the screen to its initial state, keeping its size and hooks.
+func (s *Screen) reset() {
+	s.main = newBuffer(s.width, s.height)
+	s.alt = newBuffer(s.width, s.height)
+	s.buf = s.main
+	s.cur = cursor{}
+	s.top, s.bottom = 0, s.height-1
+	s.modes = map[int]bool{ModeAutoWrap: true, ModeCursorVisible: true}
+	s.link = ""
+	s.title = ""
+	s.parser = parser{}
+	s.partial = nil
+}
+
+// Resize changes the size of the screen. Content is kept at the top left and
+// cut off or padded with blanks, and the scroll region is reset.
+func (s *Screen) Resize(width, height int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	width, height = max(width, 1), max(height, 1)
+	for _, b := range []*buffer{s.main, s.alt} {
+		lines := make([][]Cell, height)
+		for y := range lines {
+			line := blankLine(width, Style{})
+			if y < len(b.lines) {
+				copy(line, b.lines[y])
+			}
+			lines[y] = line
+		}
+		b.lines = lines
+	}
+	s.width, s.height = width, height
+	s.top, s.bottom = 0, height-1
+	s.cur.x = min(s.cur.x, width-1)
+	s.cur.y = min(s.cur.y, height-1)
+	s.cur.wrapNext = false
+}
+
+// Size returns the width and height of the screen.
+func (s *Screen) Size() (width, height int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.width, s.height
+}
+
+// Cell returns the cell at column x of row y, or a blank cell if x, y is
+// off the screen.
+func (s *Screen) Cell(x, y int) Cell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return blank(Style{})
+	}
+	return s.buf.lines[y][x]
+}
+
+// Cursor returns the cursor position and visibility.
+func (s *Screen) Cursor() Cursor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Cursor{X: s.cur.x, Y: s.cur.y, Visible: s.modes[ModeCursorVisible]}
+}
+
+// AltScreen reports whether the alternate screen is active.
+func (s *Screen) AltScreen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf == s.alt
+}
+
+// Mode reports whether the DEC private mode n is set.
+func (s *Screen) Mode(n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.modes[n]
+}
+
+// Title returns the window title last set with OSC 0 or OSC 2.
+func (s *Screen) Title() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.title
+}
+
+// Bells returns the number of BEL characters received.
+func (s *Screen) Bells() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bells
+}
+
+// OnOSC sets a function to call with each operating system command, such as
+// 8 for hyperlinks or 52 for the clipboard, and its data after the first
+// semicolon. It is called with the screen unlocked.
+func (s *Screen) OnOSC(fn func(cmd int, data string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onOSC = fn
+}
+
+// OnMode sets a function to call whenever a DEC private mode is set or reset.
+// It is called with the screen unlocked.
+func (s *Screen) OnMode(fn func(mode int, set bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onMode = fn
+}
+
+// OnBell sets a function to call for each BEL character. It is called with
+// the screen unlocked.
+func (s *Screen) OnBell(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onBell = fn
+}
+
+// Line returns the text of row y with trailing blanks removed.
+func (s *Screen) Line(y int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if y < 0 || y >= s.height {
+		return ""
+	}
+	return lineText(s.buf.lines[y])
+}
+
+// Lines returns the text of every row with trailing blanks removed.
+func (s *Screen) Lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines := make([]string, s.height)
+	for y, line := range s.buf.lines {
+		lines[y] = lineText(line)
+	}
+	return lines
+}
+
+// String returns the text of the screen, one row per line with trailing
+// blanks removed and trailing blank lines dropped.
+func (s *Screen) String() string {
+	lines := s.Lines()
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Snapshot is a copy of the state of a screen at one moment.
+type Snapshot struct {
+	Width, Height int
+
+	// Cells holds the rows of the active screen, main or alternate, from the
+	// top.
+	Cells [][]Cell
+
+	Cursor    Cursor
+	AltScreen bool
+	Title     string
+}
+
+// Snapshot returns a copy of the screen taken under a single lock. Unlike a
+// series of calls to Cell, it cannot see a write half applied.
+func (s *Screen) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cells := make([][]Cell, s.height)
+	for y, line := range s.buf.lines {
+		cells[y] = append([]Cell(nil), line...)
+	}
+	return Snapshot{
+		Width:     s.width,
+		Height:    s.height,
+		Cells:     cells,
+		Cursor:    Cursor{X: s.cur.x, Y: s.cur.y, Visible: s.modes[ModeCursorVisible]},
+		AltScreen: s.buf == s.alt,
+		Title:     s.title,
+	}
+}
+
+// Lines returns the text of every row with trailing blanks removed.
+func (sn Snapshot) Lines() []string {
+	lines := make([]string, len(sn.Cells))
+	for y, line := range sn.Cells {
+		lines[y] = lineText(line)
+	}
+	return lines
+}
+
+func lineText(line []Cell) string {
+	var b strings.Builder
+	for _, c := range line {
+		b.WriteString(c.Text())
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// print writes the character r, of the given width, at the cursor.
+func (s *Screen) print(r rune, width int) {
+	if width == 0 {
+		s.combine(r)
+		return
+	}
+	if width > s.width {
+		// Too wide for the screen at all; drop it without moving the cursor.
+		return
+	}
+
+	if s.cur.wrapNext || s.cur.x+width > s.width {
+		if s.modes[ModeAutoWrap] {
+			s.cur.x = 0
+			s.lineFeed()
+		} else {
+			s.cur.x = s.width - width
+		}
+		s.cur.wrapNext = false
+	}
+
+	line := s.buf.lines[s.cur.y]
+	s.clearWide(line, s.cur.x)
+	if width == 2 {
+		s.clearWide(line, s.cur.x+1)
+	}
+	line[s.cur.x] = Cell{Content: string(r), Width: width, Style: s.cur.style, Link: s.link}
+	if width == 2 {
+		line[s.cur.x+1] = Cell{Width: 0, Style: s.cur.style, Link: s.link}
+	}
+
+	if s.cur.x+width >= s.width {
+		s.cur.x = s.width - 1
+		s.cur.wrapNext = true
+	} else {
+		s.cur.x += width
+	}
+}
+
+// combine appends a zero-width character to the one before the cursor.
+func (s *Screen) combine(r rune) {
+	x, y := s.cur.x, s.cur.y
+	if !s.cur.wrapNext {
+		x--
+	}
+	if x < 0 {
+		return
+	}
+	line := s.buf.lines[y]
+	if line[x].Width == 0 && x > 0 {
+		x--
+	}
+	if line[x].Content != "" {
+		line[x].Content += string(r)
+	}
+}
+
+// clearWide blanks both halves of a wide character overlapping column x of
+// line, so overwriting one half never leaves the other dangling.
+func (s *Screen) clearWide(line []Cell, x int) {
+	if x >= len(line) {
+		return
+	}
+	switch {
+	case line[x].Width == 0 && x > 0:
+		line[x-1] = blank(line[x-1].Style)
+		line[x] = blank(line[x].Style)
+	case line[x].Width == 2 && x+1 < len(line):
+		line[x+1] = blank(line[x+1].Style)
+	}
+}
+
+// lineFeed moves the cursor down a line, scrolling the region if it is at
+// the bottom margin.
+func (s *Screen) lineFeed() {
+	s.cur.wrapNext = false
+	switch {
+	case s.cur.y == s.bottom:
+		s.scrollUp(1)
+	case s.cur.y < s.height-1:
+		s.cur.y++
+	}
+}
+
+// reverseIndex moves the cursor up a line, scrolling the region down if it is
+// at the top margin.
+func (s *Screen) reverseIndex() {
+	s.cur.wrapNext = false
+	switch {
+	case s.cur.y == s.top:
+		s.scrollDown(1)
+	case s.cur.y > 0:
+		s.cur.y--
+	}
+}
+
+// scrollUp moves the lines of the scroll region up by n, blanking the bottom.
+func (s *Screen) scrollUp(n int) {
+	s.deleteLinesAt(s.top, n)
+}
+
+// scrollDown moves the lines of the scroll region down by n, blanking the top.
+func (s *Screen) scrollDown(n int) {
+	s.insertLinesAt(s.top, n)
+}
+
+// insertLinesAt inserts n blank lines at row y, pushing the lines below down
+// to the bottom margin.
+func (s *Screen) insertLinesAt(y, n int) {
+	if y < s.top || y > s.bottom {
+		return
+	}
+	n = min(n, s.bottom-y+1)
+	lines := s.buf.lines
+	copy(lines[y+n:s.bottom+1], lines[y:s.bottom+1-n])
+	for i := y; i < y+n; i++ {
+		lines[i] = blankLine(s.width, s.cur.style)
+	}
+}
+
+// deleteLinesAt removes n lines at row y, pulling the lines below up and
+// blanking the bottom of the region.
+func (s *Screen) deleteLinesAt(y, n int) {
+	if y < s.top || y > s.bottom {
+		return
+	}
+	n = min(n, s.bottom-y+1)
+	lines := s.buf.lines
+	copy(lines[y:s.bottom+1-n], lines[y+n:s.bottom+1])
+	for i := s.bottom + 1 - n; i <= s.bottom; i++ {
+		lines[i] = blankLine(s.width, s.cur.style)
+	}
+}
+
+// eraseCells blanks columns [from, to) of row y.
+func (s *Screen) eraseCells(y, from, to int) {
+	line := s.buf.lines[y]
+	from, to = max(from, 0), min(to, s.width)
+	if from >= to {
+		return
+	}
+	s.clearWide(line, from)
+	s.clearWide(line, to-1)
+	for x := from; x < to; x++ {
+		line[x] = blank(s.cur.style)
+	}
+}
+
+// eraseLines blanks rows [from, to).
+func (s *Screen) eraseLines(from, to int) {
+	for y := max(from, 0); y < min(to, s.height); y++ {
+		s.buf.lines[y] = blankLine(s.width, s.cur.style)
+	}
+}
+
+// insertCells inserts n blanks at the cursor, shifting the rest of the line
+// right.
+func (s *Screen) insertCells(n int) {
+	line := s.buf.lines[s.cur.y]
+	x := s.cur.x
+	n = min(n, s.width-x)
+	s.clearWide(line, x)
+	copy(line[x+n:], line[x:s.width-n])
+	for i := x; i < x+n; i++ {
+		line[i] = blank(s.cur.style)
+	}
+	s.clearWide(line, s.width-1)
+}
+
+// deleteCells removes n cells at the cursor, shifting the rest of the line
+// left and blanking the end.
+func (s *Screen) deleteCells(n int) {
+	line := s.buf.lines[s.cur.y]
+	x := s.cur.x
+	n = min(n, s.width-x)
+	s.clearWide(line, x)
+	s.clearWide(line, x+n-1)
+	copy(line[x:], line[x+n:])
+	for i := s.width - n; i < s.width; i++ {
+		line[i] = blank(s.cur.style)
+	}
+}
+
+// moveTo moves the cursor to column x of row y, relative to the scroll
+// region in origin mode, and clamped to the screen.
+func (s *Screen) moveTo(x, y int) {
+	minY, maxY := 0, s.height-1
+	if s.cur.origin {
+		y += s.top
+		minY, maxY = s.top, s.bottom
+	}
+	s.cur.x = min(max(x, 0), s.width-1)
+	s.cur.y = min(max(y, minY), maxY)
+	s.cur.wrapNext = false
+}
+
+// moveVertically moves the cursor n rows down, or up if n is negative,
+// stopping at the scroll margins if it starts inside them.
+func (s *Screen) moveVertically(n int) {
+	minY, maxY := 0, s.height-1
+	if s.cur.y >= s.top && s.cur.y <= s.bottom {
+		minY, maxY = s.top, s.bottom
+	}
+	s.cur.y = min(max(s.cur.y+n, minY), maxY)
+	s.cur.wrapNext = false
+}
+
+// moveHorizontally moves the cursor n columns right, or left if n is
+// negative.
+func (s *Screen) moveHorizontally(n int) {
+	s.cur.x = min(max(s.cur.x+n, 0), s.width-1)
+	s.cur.wrapNext = false
+}
+
+// setScrollRegion sets the scroll region to rows [top, bottom] and homes the
+// cursor. An invalid region selects the whole screen.
+func (s *Screen) setScrollRegion(top, bottom int) {
+	if top < 0 || bottom >= s.height || top >= bottom {
+		top, bottom = 0, s.height-1
+	}
+	s.top, s.bottom = top, bottom
+	s.moveTo(0, 0)
+}
+
+// saveCursor saves the cursor state of the active buffer, as DECSC does.
+func (s *Screen) saveCursor() {
+	s.buf.saved = s.cur
+}
+
+// restoreCursor restores the state saved by saveCursor.
+func (s *Screen) restoreCursor() {
+	s.cur = s.buf.saved
+	s.cur.x = min(s.cur.x, s.width-1)
+	s.cur.y = min(s.cur.y, s.height-1)
+}
+
+// setAltScreen switches between the main and alternate screens.
+func (s *Screen) setAltScreen(on, clear bool) {
+	if on == (s.buf == s.alt) {
+		return
+	}
+	if on {
+		s.buf = s.alt
+		if clear {
+			s.eraseLines(0, s.height)
+		}
+	} else {
+		s.buf = s.main
+	}
+}
@@ -0,0 +1,273 @@
+package vt_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tmc/bubbweb/vt"
+)
+
+// screen returns a width by height screen with input written to it.
+func screen(width, height int, input string) *vt.Screen {
+	s := vt.New(width, height)
+	s.Write([]byte(input))
+	return s
+}
+
+func TestCursorMovement(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		x, y  int
+	}{
+		{"print", "abc", 3, 0},
+		{"CUP", "\x1b[3;5H", 4, 2},
+		{"CUP default", "abc\x1b[H", 0, 0},
+		{"CUP clamped", "\x1b[99;99H", 9, 4},
+		{"CUU", "\x1b[4;4H\x1b[2A", 3, 1},
+		{"CUU clamped", "\x1b[2;1H\x1b[9A", 0, 0},
+		{"CUD", "\x1b[2B", 0, 2},
+		{"CUF", "\x1b[3C", 3, 0},
+		{"CUB", "abcd\x1b[2D", 2, 0},
+		{"CNL", "ab\x1b[2E", 0, 2},
+		{"CPL", "\x1b[4;4H\x1b[F", 0, 2},
+		{"CHA", "\x1b[2;2H\x1b[7G", 6, 1},
+		{"VPA", "ab\x1b[4d", 2, 3},
+		{"CR LF", "abc\r\n", 0, 1},
+		{"BS", "abc\b", 2, 0},
+		{"HT", "a\t", 8, 0},
+		{"HT clamped", "\x1b[9G\t", 9, 0},
+		{"last column", "0123456789", 9, 0},
+		{"autowrap", "0123456789a", 1, 1},
+		{"no autowrap", "\x1b[?7l0123456789ab", 9, 0},
+		{"save restore", "\x1b[3;3H\x1b7\x1b[H\x1b8", 2, 2},
+		{"SCOSC SCORC", "\x1b[2;4H\x1b[s\x1b[5;5H\x1b[u", 3, 1},
+		{"scroll at bottom", "\x1b[5;1Ha\n", 1, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := screen(10, 5, tt.input).Cursor()
+			if c.X != tt.x || c.Y != tt.y {
+				t.Errorf("cursor at %d,%d, want %d,%d", c.X, c.Y, tt.x, tt.y)
+			}
+		})
+	}
+}
+
+func TestErase(t *testing.T) {
+	const fill = "aaaaa\r\nbbbbb\r\nccccc"
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"EL to end", fill + "\x1b[2;3H\x1b[K", []string{"aaaaa", "bb", "ccccc"}},
+		{"EL to start", fill + "\x1b[2;3H\x1b[1K", []string{"aaaaa", "   bb", "ccccc"}},
+		{"EL line", fill + "\x1b[2;3H\x1b[2K", []string{"aaaaa", "", "ccccc"}},
+		{"ED to end", fill + "\x1b[2;3H\x1b[J", []string{"aaaaa", "bb", ""}},
+		{"ED to start", fill + "\x1b[2;3H\x1b[1J", []string{"", "   bb", "ccccc"}},
+		{"ED all", fill + "\x1b[2J", []string{"", "", ""}},
+		{"ECH", fill + "\x1b[2;2H\x1b[2X", []string{"aaaaa", "b  bb", "ccccc"}},
+		{"DCH", fill + "\x1b[2;2H\x1b[2P", []string{"aaaaa", "bbb", "ccccc"}},
+		{"ICH", "abcde\x1b[1;2H\x1b[2@", []string{"a  bc", "", ""}},
+		{"IL", fill + "\x1b[2;1H\x1b[L", []string{"aaaaa", "", "bbbbb"}},
+		{"DL", fill + "\x1b[1;1H\x1b[M", []string{"bbbbb", "ccccc", ""}},
+		{"RIS", fill + "\x1bc", []string{"", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := screen(5, 3, tt.input).Lines(); !equal(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrollRegion(t *testing.T) {
+	const fill = "1\r\n2\r\n3\r\n4\r\n5"
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"line feed at bottom margin", fill + "\x1b[2;4r\x1b[4;1H\n", []string{"1", "3", "4", "", "5"}},
+		{"line feed below region", fill + "\x1b[2;4r\x1b[5;1H\n", []string{"1", "2", "3", "4", "5"}},
+		{"reverse index at top margin", fill + "\x1b[2;4r\x1b[2;1H\x1bM", []string{"1", "", "2", "3", "5"}},
+		{"SU", fill + "\x1b[2;4r\x1b[2S", []string{"1", "4", "", "", "5"}},
+		{"SD", fill + "\x1b[2;4r\x1b[T", []string{"1", "", "2", "3", "5"}},
+		{"IL inside", fill + "\x1b[2;4r\x1b[3;1H\x1b[L", []string{"1", "2", "", "3", "5"}},
+		{"DL inside", fill + "\x1b[2;4r\x1b[2;1H\x1b[M", []string{"1", "3", "4", "", "5"}},
+		{"IL outside", fill + "\x1b[2;4r\x1b[5;1H\x1b[L", []string{"1", "2", "3", "4", "5"}},
+		{"invalid region", fill + "\x1b[4;2r\x1b[5;1H\n", []string{"2", "3", "4", "5", ""}},
+		{"origin mode", "\x1b[2;4r\x1b[?6h\x1b[1;1Hx\x1b[9;1Hy", []string{"", "x", "", "y", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := screen(3, 5, tt.input).Lines(); !equal(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSGR(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  vt.Style
+	}{
+		{"none", "", vt.Style{}},
+		{"attributes", "\x1b[1;3;4;7;9m", vt.Style{Attrs: vt.Bold | vt.Italic | vt.Underline | vt.Reverse | vt.Strikethrough}},
+		{"reset", "\x1b[1;31m\x1b[m", vt.Style{}},
+		{"reset attributes", "\x1b[1;2;3;4m\x1b[22;23;24m", vt.Style{}},
+		{"underline off", "\x1b[4m\x1b[4:0m", vt.Style{}},
+		{"curly underline", "\x1b[4:3m", vt.Style{Attrs: vt.Underline}},
+		{"ANSI", "\x1b[31;42m", vt.Style{Fg: vt.IndexedColor(1), Bg: vt.IndexedColor(2)}},
+		{"bright", "\x1b[91;102m", vt.Style{Fg: vt.IndexedColor(9), Bg: vt.IndexedColor(10)}},
+		{"default colors", "\x1b[31;42m\x1b[39;49m", vt.Style{}},
+		{"256", "\x1b[38;5;208m", vt.Style{Fg: vt.IndexedColor(208)}},
+		{"256 colon", "\x1b[48:5:17m", vt.Style{Bg: vt.IndexedColor(17)}},
+		{"RGB", "\x1b[38;2;1;2;3m", vt.Style{Fg: vt.RGBColor(1, 2, 3)}},
+		{"RGB colon", "\x1b[48:2::4:5:6m", vt.Style{Bg: vt.RGBColor(4, 5, 6)}},
+		{"after extended", "\x1b[38;5;1;1m", vt.Style{Fg: vt.IndexedColor(1), Attrs: vt.Bold}},
+		{"underline color skipped", "\x1b[58;5;1;3m", vt.Style{Attrs: vt.Italic}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := screen(5, 1, tt.input+"x").Cell(0, 0).Style; got != tt.want {
+				t.Errorf("style = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWideCharacters(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		input  string
+		want   []string
+		cursor vt.Cursor
+	}{
+		{"wide", 6, "a界b", []string{"a界b", ""}, vt.Cursor{X: 4, Visible: true}},
+		{"wraps early", 4, "abc界", []string{"abc", "界"}, vt.Cursor{X: 2, Y: 1, Visible: true}},
+		{"overwrite first half", 6, "界\rx", []string{"x", ""}, vt.Cursor{X: 1, Visible: true}},
+		{"overwrite second half", 6, "界\x1b[2Gx", []string{" x", ""}, vt.Cursor{X: 2, Visible: true}},
+		{"combining", 6, "éx", []string{"éx", ""}, vt.Cursor{X: 2, Visible: true}},
+		{"erase half", 6, "界x\x1b[2G\x1b[X", []string{"  x", ""}, vt.Cursor{X: 1, Visible: true}},
+		{"no autowrap", 4, "\x1b[?7labc界", []string{"ab界", ""}, vt.Cursor{X: 3, Visible: true}},
+		{"too wide", 1, "界a", []string{"a", ""}, vt.Cursor{X: 0, Visible: true}},
+		{"too wide without autowrap", 1, "\x1b[?7l界a", []string{"a", ""}, vt.Cursor{X: 0, Visible: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := screen(tt.width, 2, tt.input)
+			if got := s.Lines(); !equal(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+			if got := s.Cursor(); got != tt.cursor {
+				t.Errorf("cursor = %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestAltScreen(t *testing.T) {
+	s := screen(5, 2, "main\x1b[?1049h")
+	if !s.AltScreen() {
+		t.Fatal("alternate screen not active after CSI ? 1049 h")
+	}
+	if got := s.String(); got != "" {
+		t.Errorf("alternate screen = %q, want it blank", got)
+	}
+	if c := s.Cursor(); c.X != 4 || c.Y != 0 {
+		t.Errorf("cursor moved to %d,%d on entering the alternate screen", c.X, c.Y)
+	}
+
+	s.Write([]byte("\x1b[2;1Halt\x1b[?1049l"))
+	if s.AltScreen() {
+		t.Fatal("alternate screen still active after CSI ? 1049 l")
+	}
+	if got := s.String(); got != "main" {
+		t.Errorf("main screen = %q, want %q", got, "main")
+	}
+	if c := s.Cursor(); c.X != 4 || c.Y != 0 {
+		t.Errorf("cursor at %d,%d, want it restored to 4,0", c.X, c.Y)
+	}
+
+	// 1047 keeps the alternate screen's content for next time.
+	s.Write([]byte("\x1b[?1047h"))
+	if got := s.String(); got != "" {
+		t.Errorf("alternate screen = %q after CSI ? 1047 h, want it cleared", got)
+	}
+	s.Write([]byte("x\x1b[?1047l\x1b[?47h"))
+	if got := s.String(); got != "    x" {
+		t.Errorf("alternate screen = %q after CSI ? 47 h, want %q", got, "    x")
+	}
+}
+
+func TestModes(t *testing.T) {
+	s := vt.New(5, 1)
+	var changes []string
+	s.OnMode(func(mode int, set bool) {
+		changes = append(changes, fmt.Sprint(mode, set))
+	})
+	s.Write([]byte("\x1b[?1000;1006h\x1b[?2004h\x1b[?1000l\x1b[?25l"))
+	for mode, want := range map[int]bool{
+		vt.ModeMouseNormal:    false,
+		vt.ModeMouseSGR:       true,
+		vt.ModeBracketedPaste: true,
+		vt.ModeAutoWrap:       true,
+	} {
+		if got := s.Mode(mode); got != want {
+			t.Errorf("Mode(%d) = %v, want %v", mode, got, want)
+		}
+	}
+	if s.Cursor().Visible {
+		t.Error("cursor visible after CSI ? 25 l")
+	}
+	want := []string{"1000 true", "1006 true", "2004 true", "1000 false", "25 false"}
+	if !equal(changes, want) {
+		t.Errorf("OnMode calls = %q, want %q", changes, want)
+	}
+}
+
+func TestSplitWrites(t *testing.T) {
+	s := vt.New(10, 1)
+	for _, b := range []byte("\x1b[31m界\x1b]2;title\x07") {
+		s.Write([]byte{b})
+	}
+	if got := s.Line(0); got != "界" {
+		t.Errorf("line = %q, want %q", got, "界")
+	}
+	if got := s.Cell(0, 0).Style.Fg; got != vt.IndexedColor(1) {
+		t.Errorf("foreground = %+v, want red", got)
+	}
+	if got := s.Title(); got != "title" {
+		t.Errorf("title = %q, want %q", got, "title")
+	}
+}
+
+func FuzzWrite(f *testing.F) {
+	f.Add(1, 5, []byte("\x1b[?7l界a"))
+	f.Add(3, 2, []byte("界\x1b[2;1r\x1b[?6h\x1b[9;9H界\x1b[3@\x1b[2P"))
+	f.Add(10, 5, []byte("\x1b[?1049h\x1b[38:2::1:2:3mhi\x1b]8;;http://x\x07link\x1b]8;;\x07\x1b[?1049l"))
+	f.Add(2, 2, []byte("é\x1b[2;2H界\x1b7\x1bc\x1b8\x1b[K"))
+	f.Fuzz(func(t *testing.T, width, height int, data []byte) {
+		s := vt.New(width%200, height%100)
+		s.Write(data)
+		w, h := s.Size()
+		if c := s.Cursor(); c.X < 0 || c.X >= w || c.Y < 0 || c.Y >= h {
+			t.Fatalf("cursor at %d,%d outside %dx%d screen", c.X, c.Y, w, h)
+		}
+		s.Write([]byte("界a"))
+		s.Resize(w/2+1, h+1)
+		s.Write(data)
+		_ = s.String()
+	})
+}
+
+func equal(a, b []string) bool {
+	return strings.Join(a, "\n") == strings.Join(b, "\n") && len(a) == len(b)
+}